@@ -0,0 +1,899 @@
+package gg
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// astLineWidth is the number of positions reserved for every line of the
+// exported file, columns beyond it are clamped to the end of the line.
+const astLineWidth = 1024
+
+// ASTFile is the go/ast representation of a Generator's output.
+type ASTFile struct {
+	// Fset holds the positions of File, File is the only file in it.
+	Fset *token.FileSet
+	File *ast.File
+
+	// Nodes maps the exported ast nodes back to the gg nodes which produced
+	// them, so analyzer results can be linked to the construction code.
+	Nodes map[ast.Node]Node
+}
+
+// AST builds a *ast.File directly from the node tree, without rendering the
+// whole file into a string first.
+//
+// Structural nodes (functions, types, statements, calls, literals, ...) are
+// converted one by one and keep a link in ASTFile.Nodes. Nodes which only
+// hold source text, like String, are parsed in place. Every node is placed
+// on its own line of a synthetic token.File, so the result could be passed
+// to go/printer, go/types or other analysis passes.
+func (g *Generator) AST() (*ASTFile, error) {
+	e := &astExporter{
+		gen:   g,
+		nodes: make(map[ast.Node]Node),
+	}
+
+	// Custom nodes could register imports while rendering.
+	g.g.render(&RenderContext{w: io.Discard, gen: g})
+
+	file := &ast.File{}
+	if g.buildConstraint != "" {
+		e.comments = append(e.comments, e.commentGroup("//go:build "+g.buildConstraint))
+		e.line++
+	}
+	if g.headerComment != "" {
+		file.Doc = e.commentGroup(formatHeaderComment(g.headerComment))
+	}
+	file.Package = e.newLine()
+
+	name := g.declaredPackage()
+	if name == "" {
+		return nil, fmt.Errorf("ast export: package name is not set")
+	}
+	file.Name = ast.NewIdent(name)
+	file.Name.NamePos = file.Package
+	e.line++
+
+	if len(g.packages) > 0 || len(g.sideImports) > 0 {
+		file.Decls = append(file.Decls, e.importDecl())
+		e.line++
+	}
+	file.Decls = append(file.Decls, e.decls(g.g.items)...)
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	fset := token.NewFileSet()
+	tf := fset.AddFile("gen.go", -1, (e.line+1)*astLineWidth)
+	lines := make([]int, e.line+1)
+	for i := range lines {
+		lines[i] = i * astLineWidth
+	}
+	tf.SetLines(lines)
+
+	file.Comments = e.comments
+	for _, f := range file.Decls {
+		if is, ok := f.(*ast.GenDecl); ok && is.Tok == token.IMPORT {
+			for _, spec := range is.Specs {
+				file.Imports = append(file.Imports, spec.(*ast.ImportSpec))
+			}
+		}
+	}
+	return &ASTFile{Fset: fset, File: file, Nodes: e.nodes}, nil
+}
+
+// formatHeaderComment converts the header into comment lines.
+func formatHeaderComment(header string) string {
+	lines := strings.Split(header, "\n")
+	for i, l := range lines {
+		lines[i] = "// " + l
+	}
+	return strings.Join(lines, "\n")
+}
+
+type astExporter struct {
+	gen      *Generator
+	nodes    map[ast.Node]Node
+	comments []*ast.CommentGroup
+
+	// line is the current line of the synthetic file, starting from 1.
+	line int
+
+	err error
+}
+
+func (e *astExporter) fail(format string, args ...interface{}) {
+	if e.err == nil {
+		e.err = fmt.Errorf("ast export: "+format, args...)
+	}
+}
+
+// pos returns the position at the start of the current line.
+func (e *astExporter) pos() token.Pos {
+	return token.Pos(1 + (e.line-1)*astLineWidth)
+}
+
+// newLine moves to the next line and returns its position.
+func (e *astExporter) newLine() token.Pos {
+	e.line++
+	return e.pos()
+}
+
+func (e *astExporter) link(a ast.Node, n Node) {
+	if a != nil && n != nil {
+		e.nodes[a] = n
+	}
+}
+
+func (e *astExporter) ident(name string) *ast.Ident {
+	return &ast.Ident{Name: name, NamePos: e.pos()}
+}
+
+func (e *astExporter) importDecl() *ast.GenDecl {
+	d := &ast.GenDecl{Tok: token.IMPORT, TokPos: e.newLine(), Lparen: e.pos()}
+	stdLib, thirdParty := e.gen.importGroups()
+	add := func(paths []string) {
+		for _, p := range paths {
+			for _, name := range e.gen.importNames(p) {
+				spec := &ast.ImportSpec{
+					Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(p), ValuePos: e.newLine()},
+				}
+				if name != "" {
+					spec.Name = e.ident(name)
+				}
+				d.Specs = append(d.Specs, spec)
+			}
+		}
+	}
+	add(stdLib)
+	if len(stdLib) > 0 && len(thirdParty) > 0 {
+		e.line++
+	}
+	add(thirdParty)
+	d.Rparen = e.newLine()
+	return d
+}
+
+// commentGroup adds comment lines starting from the next line.
+func (e *astExporter) commentGroup(text string) *ast.CommentGroup {
+	cg := &ast.CommentGroup{}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "/*") {
+		cg.List = append(cg.List, &ast.Comment{Slash: e.newLine(), Text: text})
+		e.line += strings.Count(text, "\n")
+	} else {
+		for _, l := range strings.Split(text, "\n") {
+			cg.List = append(cg.List, &ast.Comment{Slash: e.newLine(), Text: strings.TrimSpace(l)})
+		}
+	}
+	e.comments = append(e.comments, cg)
+	return cg
+}
+
+// text renders the node into source text.
+func (e *astExporter) text(n Node) string {
+	buf := pool.Get()
+	defer buf.Free()
+	n.render(&RenderContext{w: buf, gen: e.gen})
+	return buf.String()
+}
+
+// decls converts items of a declaration level group.
+func (e *astExporter) decls(items []Node) []ast.Decl {
+	var out []ast.Decl
+	var doc *ast.CommentGroup
+	for _, item := range items {
+		var ds []ast.Decl
+		switch n := item.(type) {
+		case *Group:
+			if n.open == "" && n.close == "" && n.separator == "\n" {
+				ds = e.decls(n.items)
+				break
+			}
+			ds = e.declFragment(e.text(n), n)
+		case *istring:
+			text := string(*n)
+			switch {
+			case strings.TrimSpace(text) == "":
+				e.line += strings.Count(text, "\n") + 1
+				doc = nil
+				continue
+			case isComment(text):
+				doc = e.commentGroup(text)
+				continue
+			}
+			ds = e.declFragment(text, n)
+		case *iregion:
+			e.commentGroup(userRegionBegin + n.name)
+			ds = e.decls(n.body.items)
+			e.commentGroup(userRegionEnd + n.name)
+		case *ipackage:
+			// Package clause is exported by the file itself.
+			continue
+		case *iimport:
+			ds = e.declFragment("import "+e.text(n.items), n)
+		default:
+			if d := e.decl(item); d != nil {
+				ds = []ast.Decl{d}
+			} else {
+				ds = e.declFragment(e.text(item), item)
+			}
+		}
+		if doc != nil && len(ds) > 0 {
+			switch d := ds[0].(type) {
+			case *ast.FuncDecl:
+				d.Doc = doc
+			case *ast.GenDecl:
+				d.Doc = doc
+			}
+		}
+		doc = nil
+		out = append(out, ds...)
+	}
+	return out
+}
+
+// decl converts a structural declaration node, or returns nil.
+func (e *astExporter) decl(n Node) ast.Decl {
+	var d ast.Decl
+	switch n := n.(type) {
+	case *ifunction:
+		d = e.funcDecl(n)
+	case *istruct:
+		d = e.typeDecl(n.name, n.typeParams, false, func() ast.Expr { return e.structType(n.items) })
+	case *iinterface:
+		d = e.typeDecl(n.name, n.typeParams, false, func() ast.Expr { return e.interfaceType(n.items) })
+	case *itype:
+		d = e.typeDecl(n.name, n.typeParams, n.sep == "=", func() ast.Expr { return e.expr(n.item) })
+	case *ivar:
+		d = e.valueDecl(token.VAR, n.items)
+	case *iconst:
+		d = e.valueDecl(token.CONST, n.items)
+	default:
+		return nil
+	}
+	e.link(d, n)
+	return d
+}
+
+func (e *astExporter) funcDecl(f *ifunction) *ast.FuncDecl {
+	d := &ast.FuncDecl{}
+	pos := e.newLine()
+	if f.receiver != nil {
+		d.Recv = e.fieldList([]Node{f.receiver}, true)
+	}
+	d.Name = e.ident(f.name)
+	d.Type = e.funcType(f.typeParams, f.parameters, f.results)
+	d.Type.Func = pos
+	if f.body.length() > 0 || f.call != nil {
+		d.Body = e.block(f.body.items)
+	}
+	return d
+}
+
+func (e *astExporter) funcType(typeParams, params, results *Group) *ast.FuncType {
+	ft := &ast.FuncType{Func: e.pos()}
+	if typeParams != nil && typeParams.length() > 0 {
+		ft.TypeParams = e.fieldList(typeParams.items, false)
+	}
+	ft.Params = e.fieldList(params.items, true)
+	if results.length() > 0 {
+		ft.Results = e.fieldList(results.items, true)
+	}
+	return ft
+}
+
+// fieldList converts parameters, results or type parameters.
+func (e *astExporter) fieldList(items []Node, paren bool) *ast.FieldList {
+	fl := &ast.FieldList{}
+	if paren {
+		fl.Opening = e.pos()
+	}
+	for _, item := range items {
+		var fd *ast.Field
+		switch f := item.(type) {
+		case *ifield:
+			fd = &ast.Field{Names: e.names(e.text(f.name)), Type: e.expr(f.value)}
+		case *multiNameField:
+			fd = &ast.Field{Type: e.expr(f.typ)}
+			for _, name := range f.names {
+				fd.Names = append(fd.Names, e.ident(name))
+			}
+		default:
+			fd = &ast.Field{Type: e.expr(item)}
+		}
+		e.link(fd, item)
+		fl.List = append(fl.List, fd)
+	}
+	if paren {
+		fl.Closing = e.pos()
+	}
+	return fl
+}
+
+// names splits a comma separated name list, like `a, b`.
+func (e *astExporter) names(text string) []*ast.Ident {
+	var out []*ast.Ident
+	for _, name := range strings.Split(text, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			out = append(out, e.ident(name))
+		}
+	}
+	return out
+}
+
+// typeDecl converts a type declaration, typ is called after the name has
+// been placed so the type starts on the same line.
+func (e *astExporter) typeDecl(name string, typeParams *Group, alias bool, typ func() ast.Expr) *ast.GenDecl {
+	d := &ast.GenDecl{Tok: token.TYPE, TokPos: e.newLine()}
+	spec := &ast.TypeSpec{Name: e.ident(name)}
+	if typeParams.length() > 0 {
+		spec.TypeParams = e.fieldList(typeParams.items, false)
+	}
+	if alias {
+		spec.Assign = e.pos()
+	}
+	spec.Type = typ()
+	d.Specs = []ast.Spec{spec}
+	return d
+}
+
+func (e *astExporter) structType(items *Group) *ast.StructType {
+	st := &ast.StructType{Struct: e.pos(), Fields: &ast.FieldList{Opening: e.pos()}}
+	for _, item := range items.items {
+		switch f := item.(type) {
+		case *istring:
+			if e.skipText(string(*f)) {
+				continue
+			}
+		case *ifield:
+			switch f.value.(type) {
+			case *istring, *Group:
+				// Tags and comments are kept in the text, parse the whole field.
+			default:
+				e.line++
+				fd := &ast.Field{Names: e.names(e.text(f.name)), Type: e.expr(f.value)}
+				e.link(fd, f)
+				st.Fields.List = append(st.Fields.List, fd)
+				continue
+			}
+		}
+		fragment := e.parseFragment("package p\ntype _ struct {\n", e.text(item), "\n}")
+		if fragment == nil {
+			continue
+		}
+		fields := fragment.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType).Fields.List
+		for _, fd := range fields {
+			e.link(fd, item)
+		}
+		st.Fields.List = append(st.Fields.List, fields...)
+	}
+	st.Fields.Closing = e.newLine()
+	return st
+}
+
+func (e *astExporter) interfaceType(items *Group) *ast.InterfaceType {
+	it := &ast.InterfaceType{Interface: e.pos(), Methods: &ast.FieldList{Opening: e.pos()}}
+	for _, item := range items.items {
+		switch n := item.(type) {
+		case *istring:
+			if e.skipText(string(*n)) {
+				continue
+			}
+		case *isignature:
+			e.line++
+			fd := &ast.Field{
+				Names: []*ast.Ident{e.ident(n.name)},
+				Type:  e.funcType(nil, n.parameters, n.results),
+			}
+			e.link(fd, n)
+			it.Methods.List = append(it.Methods.List, fd)
+			continue
+		}
+		e.line++
+		fd := &ast.Field{Type: e.expr(item)}
+		e.link(fd, item)
+		it.Methods.List = append(it.Methods.List, fd)
+	}
+	it.Methods.Closing = e.newLine()
+	return it
+}
+
+// skipText handles blank lines and comments inside a node list, and
+// reports whether the text has been consumed.
+func (e *astExporter) skipText(text string) bool {
+	switch {
+	case strings.TrimSpace(text) == "":
+		e.line += strings.Count(text, "\n") + 1
+		return true
+	case isComment(text):
+		e.commentGroup(text)
+		return true
+	}
+	return false
+}
+
+func (e *astExporter) valueDecl(tok token.Token, items *Group) *ast.GenDecl {
+	d := &ast.GenDecl{Tok: tok, TokPos: e.newLine()}
+	if items.length() != 1 {
+		d.Lparen = e.pos()
+	}
+	for _, item := range items.items {
+		var spec *ast.ValueSpec
+		switch f := item.(type) {
+		case *istring:
+			if e.skipText(string(*f)) {
+				continue
+			}
+			e.specLine(d)
+			spec = &ast.ValueSpec{Names: e.names(string(*f))}
+		case *ifield:
+			e.specLine(d)
+			spec = &ast.ValueSpec{Names: e.names(e.text(f.name))}
+			if f.typ != nil {
+				spec.Type = e.expr(f.typ)
+			}
+			if strings.TrimSpace(f.separator) == "=" {
+				spec.Values = e.exprList(f.value)
+			} else {
+				spec.Type = e.expr(f.value)
+			}
+		default:
+			e.fail("unsupported %s item %T", tok, item)
+			continue
+		}
+		e.link(spec, item)
+		d.Specs = append(d.Specs, spec)
+	}
+	if d.Lparen.IsValid() {
+		d.Rparen = e.newLine()
+	}
+	return d
+}
+
+// specLine moves to the next line for a spec of a grouped declaration.
+func (e *astExporter) specLine(d *ast.GenDecl) {
+	if d.Lparen.IsValid() {
+		e.line++
+	}
+}
+
+// exprList converts a node which may hold several comma separated values.
+func (e *astExporter) exprList(n Node) []ast.Expr {
+	if g, ok := n.(*Group); ok && g.open == "" && strings.TrimSpace(g.separator) == "," {
+		out := make([]ast.Expr, 0, g.length())
+		for _, item := range g.items {
+			out = append(out, e.expr(item))
+		}
+		return out
+	}
+	return []ast.Expr{e.expr(n)}
+}
+
+func (e *astExporter) block(items []Node) *ast.BlockStmt {
+	b := &ast.BlockStmt{Lbrace: e.pos()}
+	b.List = e.stmts(items)
+	b.Rbrace = e.newLine()
+	return b
+}
+
+func (e *astExporter) stmts(items []Node) []ast.Stmt {
+	var out []ast.Stmt
+	for _, item := range items {
+		out = append(out, e.stmt(item)...)
+	}
+	return out
+}
+
+func (e *astExporter) stmt(n Node) []ast.Stmt {
+	var s ast.Stmt
+	switch n := n.(type) {
+	case *istring:
+		text := string(*n)
+		if e.skipText(text) {
+			return nil
+		}
+		return e.stmtFragment(text, n)
+	case *Group:
+		switch {
+		case n.open == "" && n.close == "" && n.separator == "\n":
+			return e.stmts(n.items)
+		case n.open == "{\n":
+			e.line++
+			s = e.block(n.items)
+		default:
+			return e.stmtFragment(e.text(n), n)
+		}
+	case *iregion:
+		e.commentGroup(userRegionBegin + n.name)
+		stmts := e.stmts(n.body.items)
+		e.commentGroup(userRegionEnd + n.name)
+		return stmts
+	case *iif:
+		s = e.ifStmt(n)
+	case *ifor:
+		header := e.stmtHeader("for "+e.text(n.judge)+" {\n}", n)
+		switch h := header.(type) {
+		case *ast.ForStmt:
+			h.Body = e.block(n.body.items)
+		case *ast.RangeStmt:
+			h.Body = e.block(n.body.items)
+		default:
+			return nil
+		}
+		s = header
+	case *iswitch:
+		s = e.switchStmt(n)
+	case *ireturn:
+		e.line++
+		r := &ast.ReturnStmt{Return: e.pos()}
+		for _, item := range n.items.items {
+			r.Results = append(r.Results, e.expr(item))
+		}
+		s = r
+	case *idefer:
+		pos := e.newLine()
+		call, ok := e.expr(n.body).(*ast.CallExpr)
+		if !ok {
+			e.fail("defer body is not a function call: %s", e.text(n.body))
+			return nil
+		}
+		s = &ast.DeferStmt{Defer: pos, Call: call}
+	case *icall:
+		e.line++
+		s = &ast.ExprStmt{X: e.expr(n)}
+	default:
+		if d := e.decl(n); d != nil {
+			s = &ast.DeclStmt{Decl: d}
+			break
+		}
+		return e.stmtFragment(e.text(n), n)
+	}
+	e.link(s, n)
+	return []ast.Stmt{s}
+}
+
+func (e *astExporter) ifStmt(i *iif) ast.Stmt {
+	header, ok := e.stmtHeader("if "+e.text(i.judge)+" {\n}", i).(*ast.IfStmt)
+	if !ok {
+		return &ast.EmptyStmt{Semicolon: e.pos(), Implicit: true}
+	}
+	header.Body = e.block(i.body.items)
+	switch el := i.elseNode.(type) {
+	case nil:
+	case *iif:
+		// Keep `else if` on the line of the closing brace.
+		e.line--
+		header.Else = e.ifStmt(el)
+		e.link(header.Else, el)
+	case *Group:
+		header.Else = e.block(el.items)
+		e.link(header.Else, el)
+	default:
+		e.fail("unsupported else branch %T", el)
+	}
+	return header
+}
+
+func (e *astExporter) switchStmt(sw *iswitch) ast.Stmt {
+	judge := e.text(sw.judge)
+	header := e.stmtHeader("switch "+judge+" {\n}", sw)
+	var body *ast.BlockStmt
+	switch h := header.(type) {
+	case *ast.SwitchStmt:
+		body = h.Body
+	case *ast.TypeSwitchStmt:
+		body = h.Body
+	default:
+		return &ast.EmptyStmt{Semicolon: e.pos(), Implicit: true}
+	}
+
+	cases := sw.cases
+	if sw.defaultCase != nil {
+		cases = append(cases, sw.defaultCase)
+	}
+	for _, c := range cases {
+		cc := &ast.CaseClause{Case: e.newLine()}
+		if c.judge != nil {
+			// Parse the case list within the same kind of switch, so both
+			// expressions and types are accepted.
+			line := e.line
+			e.line -= 2
+			if fragment, ok := e.stmtHeader("switch "+judge+" {\ncase "+e.text(c.judge)+":\n}", c).(ast.Stmt); ok {
+				delete(e.nodes, fragment)
+				switch h := fragment.(type) {
+				case *ast.SwitchStmt:
+					cc.List = h.Body.List[0].(*ast.CaseClause).List
+				case *ast.TypeSwitchStmt:
+					cc.List = h.Body.List[0].(*ast.CaseClause).List
+				}
+			}
+			e.line = line
+		}
+		cc.Colon = e.pos()
+		cc.Body = e.stmts(c.body.items)
+		e.link(cc, c)
+		body.List = append(body.List, cc)
+	}
+	body.Rbrace = e.newLine()
+	return header
+}
+
+// stmtHeader parses a compound statement with an empty body, which is used
+// to convert the text based conditions of if, for and switch.
+func (e *astExporter) stmtHeader(text string, n Node) ast.Stmt {
+	stmts := e.stmtFragment(text, n)
+	if len(stmts) != 1 {
+		e.fail("invalid statement: %s", text)
+		return nil
+	}
+	// Body lines will be allocated by the caller.
+	e.line--
+	return stmts[0]
+}
+
+func (e *astExporter) expr(n Node) ast.Expr {
+	var x ast.Expr
+	switch n := n.(type) {
+	case nil:
+		return nil
+	case *qualifiedIdent:
+		switch {
+		case n.name == "":
+			x = e.ident(n.pkg.alias)
+		case n.pkg.alias == "":
+			x = e.ident(n.name)
+		default:
+			x = &ast.SelectorExpr{X: e.ident(n.pkg.alias), Sel: e.ident(n.name)}
+		}
+	case *icall:
+		x = e.callExpr(n)
+	case *ivalue:
+		var typ ast.Expr
+		if strings.TrimSpace(e.text(n.typ)) != "" {
+			typ = e.expr(n.typ)
+		}
+		x = e.compositeLit(typ, n.items, n.multiLine)
+	case *islice:
+		x = e.compositeLit(&ast.ArrayType{Lbrack: e.pos(), Elt: e.expr(n.elemType)}, n.items, n.multiLine)
+	case *iarray:
+		x = e.compositeLit(&ast.ArrayType{
+			Lbrack: e.pos(),
+			Len:    &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(n.size), ValuePos: e.pos()},
+			Elt:    e.expr(n.elemType),
+		}, n.items, n.multiLine)
+	case *sliceType:
+		x = &ast.ArrayType{Lbrack: e.pos(), Elt: e.expr(n.elem)}
+	case *ptrType:
+		x = &ast.StarExpr{Star: e.pos(), X: e.expr(n.elem)}
+	case *mapType:
+		x = &ast.MapType{Map: e.pos(), Key: e.expr(n.key), Value: e.expr(n.value)}
+	case *chanType:
+		dir := ast.SEND | ast.RECV
+		switch n.dir {
+		case chanRecv:
+			dir = ast.RECV
+		case chanSend:
+			dir = ast.SEND
+		}
+		x = &ast.ChanType{Begin: e.pos(), Dir: dir, Value: e.expr(n.elem)}
+	case *genericType:
+		base := e.expr(n.base)
+		args := make([]ast.Expr, 0, len(n.args))
+		for _, arg := range n.args {
+			args = append(args, e.expr(arg))
+		}
+		if len(args) == 1 {
+			x = &ast.IndexExpr{X: base, Lbrack: e.pos(), Index: args[0], Rbrack: e.pos()}
+		} else {
+			x = &ast.IndexListExpr{X: base, Lbrack: e.pos(), Indices: args, Rbrack: e.pos()}
+		}
+	case *ifunction:
+		if n.name != "" {
+			e.fail("named function %s used as expression", n.name)
+			return nil
+		}
+		fl := &ast.FuncLit{Type: e.funcType(nil, n.parameters, n.results)}
+		fl.Body = e.block(n.body.items)
+		x = fl
+		if n.call != nil {
+			x = e.call(fl, n.call.items.items)
+		}
+	default:
+		x = e.exprFragment(e.text(n), n)
+	}
+	e.link(x, n)
+	return x
+}
+
+func (e *astExporter) callExpr(c *icall) ast.Expr {
+	var fun ast.Expr
+	switch owner := c.owner.(type) {
+	case nil:
+		fun = e.ident(c.name)
+	case *qualifiedIdent:
+		if owner.name == "" {
+			fun = &ast.SelectorExpr{X: e.ident(owner.pkg.alias), Sel: e.ident(c.name)}
+			break
+		}
+		fun = &ast.SelectorExpr{X: e.expr(owner), Sel: e.ident(c.name)}
+	default:
+		fun = &ast.SelectorExpr{X: e.expr(owner), Sel: e.ident(c.name)}
+	}
+
+	var x ast.Expr = e.call(fun, c.items.items)
+	for _, item := range c.calls.items {
+		next, ok := item.(*icall)
+		if !ok {
+			e.fail("unsupported chained call %T", item)
+			continue
+		}
+		x = e.call(&ast.SelectorExpr{X: x, Sel: e.ident(next.name)}, next.items.items)
+		e.link(x, next)
+	}
+	return x
+}
+
+// args converts call arguments, the last one could be spread with `...`.
+// call converts a call of fun with the arguments, a last argument spread
+// with `...` sets the ellipsis, like `append(a, b...)`.
+func (e *astExporter) call(fun ast.Expr, items []Node) *ast.CallExpr {
+	c := &ast.CallExpr{Fun: fun, Lparen: e.pos()}
+	c.Args = make([]ast.Expr, 0, len(items))
+	for idx, item := range items {
+		if idx == len(items)-1 {
+			if text := strings.TrimSpace(e.text(item)); strings.HasSuffix(text, "...") {
+				c.Args = append(c.Args, e.exprFragment(strings.TrimSuffix(text, "..."), item))
+				c.Ellipsis = e.pos()
+				continue
+			}
+		}
+		c.Args = append(c.Args, e.expr(item))
+	}
+	c.Rparen = e.pos()
+	return c
+}
+
+// compositeLit converts literal elements, a multi-line literal places every
+// element and the closing brace on their own lines.
+func (e *astExporter) compositeLit(typ ast.Expr, items *Group, multiLine bool) *ast.CompositeLit {
+	cl := &ast.CompositeLit{Type: typ, Lbrace: e.pos()}
+	multiLine = multiLine && items.length() > 0
+	for _, item := range items.items {
+		if multiLine {
+			e.line++
+		}
+		if f, ok := item.(*ifield); ok && strings.TrimSpace(f.separator) == ":" {
+			kv := &ast.KeyValueExpr{Key: e.expr(f.name), Colon: e.pos(), Value: e.expr(f.value)}
+			e.link(kv, f)
+			cl.Elts = append(cl.Elts, kv)
+			continue
+		}
+		cl.Elts = append(cl.Elts, e.expr(item))
+	}
+	if multiLine {
+		e.line++
+	}
+	cl.Rbrace = e.pos()
+	return cl
+}
+
+func (e *astExporter) exprFragment(text string, n Node) ast.Expr {
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(text), "..."); ok {
+		// Variadic parameter types are not valid expressions.
+		x := &ast.Ellipsis{Ellipsis: e.pos(), Elt: e.exprFragment(rest, nil)}
+		e.link(x, n)
+		return x
+	}
+	fset := token.NewFileSet()
+	x, err := parser.ParseExprFrom(fset, "", text, 0)
+	if err != nil {
+		e.fail("parse expression %q: %v", text, err)
+		return &ast.BadExpr{From: e.pos(), To: e.pos()}
+	}
+	// Expressions continue the current line.
+	e.placeFragment(fset, x, e.line, 0)
+	e.line += strings.Count(text, "\n")
+	e.link(x, n)
+	return x
+}
+
+func (e *astExporter) stmtFragment(text string, n Node) []ast.Stmt {
+	f := e.parseFragment("package p\nfunc _() {\n", text, "\n}")
+	if f == nil {
+		return nil
+	}
+	stmts := f.Decls[0].(*ast.FuncDecl).Body.List
+	for _, s := range stmts {
+		e.link(s, n)
+	}
+	return stmts
+}
+
+func (e *astExporter) declFragment(text string, n Node) []ast.Decl {
+	f := e.parseFragment("package p\n", text, "")
+	if f == nil {
+		return nil
+	}
+	for _, d := range f.Decls {
+		e.link(d, n)
+	}
+	return f.Decls
+}
+
+// parseFragment parses a source text wrapped into a file, and places the
+// text on the lines following the current line.
+func (e *astExporter) parseFragment(prefix, text, suffix string) *ast.File {
+	fset := token.NewFileSet()
+	src := prefix + text + suffix
+	skip := strings.Count(prefix, "\n")
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		e.fail("parse %q: %v", src, err)
+		return nil
+	}
+	e.placeFragment(fset, f, e.line+1, skip)
+	e.comments = append(e.comments, f.Comments...)
+	e.line += strings.Count(text, "\n") + 1
+	return f
+}
+
+// placeFragment maps all positions of a parsed fragment, so the first line
+// after skip lines is placed at the given line.
+func (e *astExporter) placeFragment(fset *token.FileSet, root ast.Node, first, skip int) {
+	mapPos := func(p token.Pos) token.Pos {
+		if !p.IsValid() {
+			return p
+		}
+		position := fset.Position(p)
+		line := first + position.Line - 1 - skip
+		if line < first {
+			line = first
+		}
+		col := position.Column - 1
+		if col >= astLineWidth {
+			col = astLineWidth - 1
+		}
+		return token.Pos(1 + (line-1)*astLineWidth + col)
+	}
+
+	posType := reflect.TypeOf(token.NoPos)
+	seen := make(map[ast.Node]bool)
+	visit := func(n ast.Node) bool {
+		if n == nil || seen[n] {
+			return false
+		}
+		seen[n] = true
+		v := reflect.ValueOf(n)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+		if v.Kind() != reflect.Struct {
+			return true
+		}
+		for i := 0; i < v.NumField(); i++ {
+			fv := v.Field(i)
+			if fv.Type() == posType && fv.CanSet() {
+				fv.Set(reflect.ValueOf(mapPos(token.Pos(fv.Int()))))
+			}
+		}
+		return true
+	}
+	ast.Inspect(root, visit)
+	if f, ok := root.(*ast.File); ok {
+		// Comments which are not attached to any node are only reachable
+		// from the file.
+		for _, cg := range f.Comments {
+			ast.Inspect(cg, visit)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package gg
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func newASTExportGenerator() (*Generator, *ifunction) {
+	gen := New()
+	gen.SetPackage("example")
+	gen.SetHeader("Code generated by gg. DO NOT EDIT.")
+
+	ctx := gen.P("context")
+	errs := gen.P("github.com/pkg/errors")
+
+	gen.Body().AddLineComment("User is a user.")
+	gen.Body().NewStruct("User").
+		AddField("ID", "int64").
+		AddField("Name", "string")
+	gen.Body().AddLine()
+	gen.Body().NewConst().AddField("A", Lit(1)).AddField("B", Lit(2))
+	gen.Body().AddLine()
+	gen.Body().NewVar().AddField("defaultUser", Value("User").AddField("ID", Lit(1)).AddField("Name", Lit("root")).MultiLine())
+	gen.Body().AddLine()
+
+	fn := gen.Body().NewFunction("Load").
+		WithReceiver("u", "*User").
+		AddParameter("ctx", ctx.Type("Context")).
+		AddResult("", "error")
+	fn.AddBody(
+		If("u == nil").AddBody(Return(errs.Call("New").AddParameter(Lit("nil user")))),
+		For("i := 0; i < 3; i++").AddBody(String("u.ID++")),
+		Return(String("nil")),
+	)
+	return gen, fn
+}
+
+func TestGeneratorAST(t *testing.T) {
+	gen, fn := newASTExportGenerator()
+
+	file, err := gen.AST()
+	if err != nil {
+		t.Fatalf("AST() error: %v", err)
+	}
+
+	var got bytes.Buffer
+	if err := format.Node(&got, file.Fset, file.File); err != nil {
+		t.Fatalf("format.Node error: %v", err)
+	}
+	expected, err := format.Source(gen.Bytes())
+	if err != nil {
+		t.Fatalf("format.Source error: %v", err)
+	}
+	if got.String() != string(expected) {
+		t.Errorf("AST output mismatch.\nExpected:\n%s\nGot:\n%s", expected, got.String())
+	}
+
+	if len(file.File.Imports) != 2 {
+		t.Errorf("Expected 2 imports, got %d", len(file.File.Imports))
+	}
+
+	var decl *ast.FuncDecl
+	for _, d := range file.File.Decls {
+		if f, ok := d.(*ast.FuncDecl); ok {
+			decl = f
+		}
+	}
+	if decl == nil || file.Nodes[decl] != fn {
+		t.Errorf("Expected FuncDecl linked to the function node")
+	}
+	if pos := file.Fset.Position(decl.Pos()); !pos.IsValid() || pos.Line <= 1 {
+		t.Errorf("Expected valid position for FuncDecl, got %v", pos)
+	}
+}
+
+func TestGeneratorAST_Imported(t *testing.T) {
+	fset := token.NewFileSet()
+	src, err := parser.ParseFile(fset, "source.go", astImportSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen := New()
+	gen.SetPackage("target")
+	if err := NewASTImporter(gen, fset, src).File(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := gen.AST()
+	if err != nil {
+		t.Fatalf("AST() error: %v", err)
+	}
+	var got bytes.Buffer
+	if err := format.Node(&got, file.Fset, file.File); err != nil {
+		t.Fatalf("format.Node error: %v", err)
+	}
+
+	expected, err := format.Source(gen.Bytes())
+	if err != nil {
+		t.Fatalf("format.Source error: %v", err)
+	}
+	// The rendered text keeps an empty line in the empty default case.
+	want := strings.Replace(string(expected), "\tdefault:\n\n", "\tdefault:\n", 1)
+	if got.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got.String())
+	}
+}
+
+func TestGeneratorAST_Ellipsis(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().NewFunction("join").
+		AddParameter("a", "[]int").
+		AddParameter("b", "[]int").
+		AddResult("", "[]int").
+		AddBody(Return(Call("append").AddParameter("a", "b...")))
+
+	file, err := gen.AST()
+	if err != nil {
+		t.Fatalf("AST() error: %v", err)
+	}
+	var got bytes.Buffer
+	if err := format.Node(&got, file.Fset, file.File); err != nil {
+		t.Fatalf("format.Node error: %v", err)
+	}
+	if !strings.Contains(got.String(), "return append(a, b...)") {
+		t.Errorf("Expected the spread argument, got:\n%s", got.String())
+	}
+}
+
+func TestGeneratorAST_Comments(t *testing.T) {
+	gen, _ := newASTExportGenerator()
+
+	file, err := gen.AST()
+	if err != nil {
+		t.Fatalf("AST() error: %v", err)
+	}
+	if file.File.Doc == nil || !strings.Contains(file.File.Doc.Text(), "DO NOT EDIT") {
+		t.Errorf("Expected header comment as file doc")
+	}
+	for _, d := range file.File.Decls {
+		if g, ok := d.(*ast.GenDecl); ok && g.Tok.String() == "type" {
+			if g.Doc == nil || g.Doc.Text() != "User is a user.\n" {
+				t.Errorf("Expected doc comment on User, got %v", g.Doc)
+			}
+		}
+	}
+}
+
+func TestGeneratorAST_Error(t *testing.T) {
+	gen := New()
+	gen.SetPackage("example")
+	gen.Body().AddString("func (")
+
+	if _, err := gen.AST(); err == nil {
+		t.Error("Expected error for invalid source text")
+	}
+}
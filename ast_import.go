@@ -0,0 +1,750 @@
+package gg
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// ASTImporter converts go/ast declarations, statements and expressions into
+// gg nodes, so existing source can be copied into generated output and
+// modified with the usual builder methods.
+//
+// Selector expressions on packages imported by the source file are turned
+// into PackageRef references of the target Generator, so the imports follow
+// the code and aliases are resolved against the target file.
+//
+// Example:
+//
+//	file, _ := parser.ParseFile(fset, "user.go", src, parser.ParseComments)
+//	im := NewASTImporter(gen, fset, file)
+//	fn, _ := im.FuncDecl(file.Decls[1].(*ast.FuncDecl))
+//	fn.AddParameter("ctx", gen.P("context").Type("Context"))
+//	gen.Body().Append(fn)
+type ASTImporter struct {
+	gen  *Generator
+	fset *token.FileSet
+	file *ast.File
+
+	// imports maps the local package name in the source file to its
+	// import, and packages to the PackageRef of the target generator, which
+	// is registered when the package is used by converted syntax.
+	imports  map[string]*ast.ImportSpec
+	packages map[string]*PackageRef
+
+	err error
+}
+
+// NewASTImporter creates an importer which converts syntax from file into
+// nodes of gen. The file is used to resolve imported packages and comments,
+// and fset is used to keep multi-line composite literals and trailing
+// comments; both could be nil while the converted syntax doesn't need them.
+// Imported packages are added to gen once converted syntax uses them, blank
+// and dot imports are added at once.
+func NewASTImporter(gen *Generator, fset *token.FileSet, file *ast.File) *ASTImporter {
+	i := &ASTImporter{
+		gen:      gen,
+		fset:     fset,
+		file:     file,
+		imports:  make(map[string]*ast.ImportSpec),
+		packages: make(map[string]*PackageRef),
+	}
+	if file == nil {
+		return i
+	}
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		var name string
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		switch name {
+		case "_":
+			// Blank and dot imports can't be referenced by a selector.
+			gen.ImportBlank(importPath)
+		case ".":
+			gen.ImportDot(importPath)
+		case "":
+			i.imports[resolvePackageAlias(importPath, nil)] = spec
+		default:
+			i.imports[name] = spec
+		}
+	}
+	return i
+}
+
+// File converts all declarations of the importer's file and appends them,
+// with their doc comments, into the generator's body.
+func (i *ASTImporter) File() error {
+	if i.file == nil {
+		return fmt.Errorf("ast importer: no file to import")
+	}
+	body := i.gen.Body()
+	for idx, d := range i.file.Decls {
+		n, err := i.Decl(d)
+		if err != nil {
+			return err
+		}
+		if n == nil {
+			continue
+		}
+		if idx > 0 {
+			body.AddLine()
+		}
+		body.Append(i.docComment(declDoc(d))...)
+		body.Append(n)
+	}
+	return nil
+}
+
+// Decl converts a declaration into a node.
+// Import declarations are registered in the generator and return nil.
+func (i *ASTImporter) Decl(d ast.Decl) (Node, error) {
+	i.err = nil
+	n := i.decl(d)
+	return n, i.err
+}
+
+// FuncDecl converts a function or method declaration.
+func (i *ASTImporter) FuncDecl(d *ast.FuncDecl) (*ifunction, error) {
+	i.err = nil
+	f := i.funcDecl(d)
+	return f, i.err
+}
+
+// Stmt converts a statement into a node.
+func (i *ASTImporter) Stmt(s ast.Stmt) (Node, error) {
+	i.err = nil
+	n := i.stmt(s)
+	return n, i.err
+}
+
+// Expr converts an expression (including type expressions) into a node.
+func (i *ASTImporter) Expr(e ast.Expr) (Node, error) {
+	i.err = nil
+	n := i.expr(e)
+	return n, i.err
+}
+
+// fail records the first error met while converting, and returns a
+// placeholder node so the conversion can go on.
+func (i *ASTImporter) fail(format string, args ...interface{}) Node {
+	if i.err == nil {
+		i.err = fmt.Errorf("ast importer: "+format, args...)
+	}
+	return String("")
+}
+
+func declDoc(d ast.Decl) *ast.CommentGroup {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+func (i *ASTImporter) docComment(cg *ast.CommentGroup) []interface{} {
+	if cg == nil {
+		return nil
+	}
+	out := make([]interface{}, 0, len(cg.List))
+	for _, c := range cg.List {
+		out = append(out, String(c.Text))
+	}
+	return out
+}
+
+func (i *ASTImporter) decl(d ast.Decl) Node {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		return i.funcDecl(d)
+	case *ast.GenDecl:
+		return i.genDecl(d)
+	}
+	return i.fail("unsupported declaration %T", d)
+}
+
+func (i *ASTImporter) funcDecl(d *ast.FuncDecl) *ifunction {
+	f := Function(d.Name.Name)
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		recv := d.Recv.List[0]
+		name := ""
+		if len(recv.Names) > 0 {
+			name = recv.Names[0].Name
+		}
+		f.WithReceiver(name, i.expr(recv.Type))
+	}
+	i.fieldList(d.Type.TypeParams, f.typeParams)
+	i.fieldList(d.Type.Params, f.parameters)
+	i.fieldList(d.Type.Results, f.results)
+	if d.Body != nil {
+		f.body.append(i.stmtList(d.Body.List, d.Body.Lbrace, d.Body.Rbrace)...)
+		if f.body.length() == 0 || i.endsWithLineComment(d.Body.List, d.Body.Lbrace, d.Body.Rbrace) {
+			// Keep the body so the declaration doesn't become external, and
+			// the closing brace out of a line comment.
+			f.body.append(String(""))
+		}
+	}
+	return f
+}
+
+// fieldList appends fields of a parameter, result or type parameter list
+// into the group.
+func (i *ASTImporter) fieldList(fl *ast.FieldList, g *Group) {
+	if fl == nil {
+		return
+	}
+	for _, fd := range fl.List {
+		typ := i.expr(fd.Type)
+		switch len(fd.Names) {
+		case 0:
+			g.append(field("", typ, ""))
+		case 1:
+			g.append(field(fd.Names[0].Name, typ, " "))
+		default:
+			g.append(&multiNameField{names: identNames(fd.Names), typ: typ})
+		}
+	}
+}
+
+func identNames(idents []*ast.Ident) []string {
+	names := make([]string, 0, len(idents))
+	for _, id := range idents {
+		names = append(names, id.Name)
+	}
+	return names
+}
+
+func (i *ASTImporter) genDecl(d *ast.GenDecl) Node {
+	switch d.Tok {
+	case token.IMPORT:
+		// Imports are managed by the generator, registered in NewASTImporter.
+		return nil
+	case token.TYPE:
+		if len(d.Specs) == 1 {
+			return i.typeSpec(d.Specs[0].(*ast.TypeSpec))
+		}
+		g := NewGroup()
+		for _, spec := range d.Specs {
+			g.append(i.typeSpec(spec.(*ast.TypeSpec)))
+		}
+		return g
+	case token.CONST:
+		c := Const()
+		for _, spec := range d.Specs {
+			i.valueSpec(spec.(*ast.ValueSpec), c.items)
+		}
+		return c
+	case token.VAR:
+		v := Var()
+		for _, spec := range d.Specs {
+			i.valueSpec(spec.(*ast.ValueSpec), v.items)
+		}
+		return v
+	}
+	return i.fail("unsupported declaration token %s", d.Tok)
+}
+
+func (i *ASTImporter) typeSpec(s *ast.TypeSpec) Node {
+	name := s.Name.Name
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		if s.Assign.IsValid() {
+			break
+		}
+		st := Struct(name)
+		i.fieldList(s.TypeParams, st.typeParams)
+		for _, fd := range t.Fields.List {
+			st.items.append(i.docComment(fd.Doc)...)
+			st.items.append(i.structField(fd))
+		}
+		return st
+	case *ast.InterfaceType:
+		if s.Assign.IsValid() {
+			break
+		}
+		it := Interface(name)
+		i.fieldList(s.TypeParams, it.typeParams)
+		for _, m := range t.Methods.List {
+			it.items.append(i.docComment(m.Doc)...)
+			ft, ok := m.Type.(*ast.FuncType)
+			if !ok || len(m.Names) == 0 {
+				it.AddEmbedded(i.expr(m.Type))
+				continue
+			}
+			sig := it.NewFunction(m.Names[0].Name)
+			i.fieldList(ft.Params, sig.parameters)
+			i.fieldList(ft.Results, sig.results)
+		}
+		return it
+	}
+
+	var ty *itype
+	if s.Assign.IsValid() {
+		ty = TypeAlias(name, i.expr(s.Type))
+	} else {
+		ty = Type(name, i.expr(s.Type))
+	}
+	i.fieldList(s.TypeParams, ty.typeParams)
+	return ty
+}
+
+// structField renders a struct field with its tag and trailing comment.
+func (i *ASTImporter) structField(fd *ast.Field) Node {
+	typ := NewInlineGroup().Append(i.expr(fd.Type))
+	if fd.Tag != nil {
+		typ.Append(" ", fd.Tag.Value)
+	}
+	if fd.Comment != nil {
+		for _, c := range fd.Comment.List {
+			typ.Append(" ", c.Text)
+		}
+	}
+	if len(fd.Names) == 0 {
+		return typ
+	}
+	return field(strings.Join(identNames(fd.Names), ", "), typ, " ")
+}
+
+func (i *ASTImporter) valueSpec(s *ast.ValueSpec, items *Group) {
+	items.append(i.docComment(s.Doc)...)
+	name := strings.Join(identNames(s.Names), ", ")
+
+	switch {
+	case len(s.Values) == 0 && s.Type == nil:
+		// Implicit repetition in a const block, like `B` after `A = iota`.
+		items.append(String(name))
+	case len(s.Values) == 0:
+		items.append(field(name, i.expr(s.Type), " "))
+	case s.Type == nil:
+		items.append(field(name, i.exprList(s.Values), "="))
+	default:
+		items.append(typedField(name, i.expr(s.Type), i.exprList(s.Values), "="))
+	}
+}
+
+// stmtList converts statements of a block, keeping the comments between
+// them. open and close are the positions of the block's braces.
+func (i *ASTImporter) stmtList(list []ast.Stmt, open, close token.Pos) []interface{} {
+	out := make([]interface{}, 0, len(list))
+	prev := open
+	for idx, s := range list {
+		out = append(out, i.commentsBetween(prev, s.Pos())...)
+		n := i.stmt(s)
+		prev = s.End()
+
+		next := close
+		if idx+1 < len(list) {
+			next = list[idx+1].Pos()
+		}
+		if comments := i.trailingComments(prev, next); len(comments) > 0 {
+			// Keep the comments on the line of the statement.
+			line := concat(n)
+			for _, c := range comments {
+				line.Append(" ", c.Text)
+			}
+			n = line
+			prev = comments[len(comments)-1].End()
+		}
+		out = append(out, n)
+	}
+	out = append(out, i.commentsBetween(prev, close)...)
+	return out
+}
+
+// endsWithLineComment reports whether the last comment of a block is a line
+// comment after its statements, which must not be followed by the closing
+// brace on the same line.
+func (i *ASTImporter) endsWithLineComment(list []ast.Stmt, open, close token.Pos) bool {
+	if i.file == nil {
+		return false
+	}
+	from := open
+	if len(list) > 0 {
+		from = list[len(list)-1].End()
+	}
+	var last *ast.Comment
+	for _, cg := range i.file.Comments {
+		for _, c := range cg.List {
+			if c.Pos() >= from && c.End() <= close {
+				last = c
+			}
+		}
+	}
+	return last != nil && strings.HasPrefix(last.Text, "//")
+}
+
+// trailingComments returns the comments which start on the line of pos,
+// before to.
+func (i *ASTImporter) trailingComments(pos, to token.Pos) []*ast.Comment {
+	if i.file == nil || i.fset == nil || !pos.IsValid() {
+		return nil
+	}
+	line := i.fset.Position(pos).Line
+	var out []*ast.Comment
+	for _, cg := range i.file.Comments {
+		for _, c := range cg.List {
+			if c.Pos() >= pos && c.End() <= to && i.fset.Position(c.Pos()).Line == line {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+// commentsBetween returns comments located between from and to.
+func (i *ASTImporter) commentsBetween(from, to token.Pos) []interface{} {
+	if i.file == nil || !from.IsValid() || !to.IsValid() {
+		return nil
+	}
+	var out []interface{}
+	for _, cg := range i.file.Comments {
+		for _, c := range cg.List {
+			// A group could start with the trailing comment of a statement.
+			if c.Pos() >= from && c.End() <= to {
+				out = append(out, String(c.Text))
+			}
+		}
+	}
+	return out
+}
+
+func (i *ASTImporter) block(b *ast.BlockStmt) *Group {
+	g := newGroup("{\n", "\n}", "\n")
+	if b != nil {
+		g.append(i.stmtList(b.List, b.Lbrace, b.Rbrace)...)
+	}
+	return g
+}
+
+func (i *ASTImporter) stmt(s ast.Stmt) Node {
+	switch s := s.(type) {
+	case nil:
+		return String("")
+	case *ast.ExprStmt:
+		return i.expr(s.X)
+	case *ast.AssignStmt:
+		return concat(i.exprList(s.Lhs), " ", s.Tok.String(), " ", i.exprList(s.Rhs))
+	case *ast.DeclStmt:
+		return i.decl(s.Decl)
+	case *ast.ReturnStmt:
+		r := Return()
+		for _, e := range s.Results {
+			r.items.append(i.expr(e))
+		}
+		return r
+	case *ast.IfStmt:
+		return i.ifStmt(s)
+	case *ast.ForStmt:
+		judge := NewInlineGroup()
+		if s.Init != nil || s.Post != nil {
+			judge.Append(i.stmt(s.Init), "; ", i.exprOrEmpty(s.Cond), "; ", i.stmt(s.Post), " ")
+		} else if s.Cond != nil {
+			judge.Append(i.expr(s.Cond), " ")
+		}
+		f := For(judge)
+		f.body = i.block(s.Body)
+		return f
+	case *ast.RangeStmt:
+		judge := NewInlineGroup()
+		if s.Key != nil {
+			judge.Append(i.expr(s.Key))
+			if s.Value != nil {
+				judge.Append(", ", i.expr(s.Value))
+			}
+			judge.Append(" ", s.Tok.String(), " ")
+		}
+		judge.Append("range ", i.expr(s.X), " ")
+		f := For(judge)
+		f.body = i.block(s.Body)
+		return f
+	case *ast.SwitchStmt:
+		judge := NewInlineGroup()
+		if s.Init != nil {
+			judge.Append(i.stmt(s.Init), "; ")
+		}
+		if s.Tag != nil {
+			judge.Append(i.expr(s.Tag), " ")
+		}
+		return i.switchStmt(judge, s.Body)
+	case *ast.TypeSwitchStmt:
+		judge := NewInlineGroup()
+		if s.Init != nil {
+			judge.Append(i.stmt(s.Init), "; ")
+		}
+		judge.Append(i.stmt(s.Assign), " ")
+		return i.switchStmt(judge, s.Body)
+	case *ast.SelectStmt:
+		g := newGroup("select {\n", "\n}", "\n")
+		for idx, c := range s.Body.List {
+			cc := c.(*ast.CommClause)
+			ic := &icase{body: newGroup("\n", "", "\n")}
+			if cc.Comm != nil {
+				ic.judge = i.stmt(cc.Comm)
+			}
+			ic.body.append(i.stmtList(cc.Body, cc.Colon, clauseEnd(s.Body, idx))...)
+			g.append(ic)
+		}
+		return g
+	case *ast.BlockStmt:
+		return i.block(s)
+	case *ast.IncDecStmt:
+		return concat(i.expr(s.X), s.Tok.String())
+	case *ast.DeferStmt:
+		return Defer(i.expr(s.Call))
+	case *ast.GoStmt:
+		return concat("go ", i.expr(s.Call))
+	case *ast.BranchStmt:
+		if s.Label != nil {
+			return String("%s %s", s.Tok, s.Label.Name)
+		}
+		return String(s.Tok.String())
+	case *ast.LabeledStmt:
+		return concat(s.Label.Name, ":\n", i.stmt(s.Stmt))
+	case *ast.SendStmt:
+		return concat(i.expr(s.Chan), " <- ", i.expr(s.Value))
+	case *ast.EmptyStmt:
+		return String("")
+	}
+	return i.fail("unsupported statement %T", s)
+}
+
+func (i *ASTImporter) ifStmt(s *ast.IfStmt) *iif {
+	judge := NewInlineGroup()
+	if s.Init != nil {
+		judge.Append(i.stmt(s.Init), "; ")
+	}
+	judge.Append(i.expr(s.Cond), " ")
+
+	ii := If(judge)
+	ii.body = i.block(s.Body)
+	switch e := s.Else.(type) {
+	case *ast.IfStmt:
+		ii.elseNode = i.ifStmt(e)
+	case *ast.BlockStmt:
+		ii.elseNode = i.block(e)
+	}
+	return ii
+}
+
+func (i *ASTImporter) switchStmt(judge Node, body *ast.BlockStmt) *iswitch {
+	sw := Switch(judge)
+	for idx, c := range body.List {
+		cc := c.(*ast.CaseClause)
+		var ic *icase
+		if cc.List == nil {
+			ic = sw.NewDefault()
+		} else {
+			ic = sw.NewCase(i.exprList(cc.List))
+		}
+		ic.body.append(i.stmtList(cc.Body, cc.Colon, clauseEnd(body, idx))...)
+	}
+	return sw
+}
+
+// clauseEnd returns the end of the comments of the clause at idx, which is
+// the next clause or the closing brace.
+func clauseEnd(body *ast.BlockStmt, idx int) token.Pos {
+	if idx+1 < len(body.List) {
+		return body.List[idx+1].Pos()
+	}
+	return body.Rbrace
+}
+
+func (i *ASTImporter) exprOrEmpty(e ast.Expr) Node {
+	if e == nil {
+		return String("")
+	}
+	return i.expr(e)
+}
+
+func (i *ASTImporter) exprList(list []ast.Expr) Node {
+	g := newGroup("", "", ", ")
+	for _, e := range list {
+		g.append(i.expr(e))
+	}
+	return g
+}
+
+// packageRef returns the PackageRef if e is an identifier of an imported package.
+func (i *ASTImporter) packageRef(e ast.Expr) *PackageRef {
+	id, ok := e.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	if id.Obj != nil {
+		// Shadowed by a local declaration, Obj is the only resolution we
+		// could get without type checking.
+		return nil
+	}
+	if pkg, ok := i.packages[id.Name]; ok {
+		return pkg
+	}
+	spec, ok := i.imports[id.Name]
+	if !ok {
+		return nil
+	}
+	importPath, _ := strconv.Unquote(spec.Path.Value)
+	var pkg *PackageRef
+	if existing, ok := i.gen.aliasToPath[id.Name]; spec.Name == nil || (ok && existing != importPath) {
+		// P resolves the alias of unnamed imports, and of aliases taken in
+		// the target file.
+		pkg = i.gen.P(importPath)
+	} else {
+		pkg = i.gen.PAlias(importPath, id.Name)
+	}
+	i.packages[id.Name] = pkg
+	return pkg
+}
+
+func (i *ASTImporter) expr(e ast.Expr) Node {
+	switch e := e.(type) {
+	case nil:
+		return String("")
+	case *ast.Ident:
+		return String(e.Name)
+	case *ast.BasicLit:
+		return String(e.Value)
+	case *ast.SelectorExpr:
+		if pkg := i.packageRef(e.X); pkg != nil {
+			return pkg.Dot(e.Sel.Name)
+		}
+		return concat(i.expr(e.X), ".", e.Sel.Name)
+	case *ast.CallExpr:
+		return i.callExpr(e)
+	case *ast.CompositeLit:
+		v := Value(i.exprOrEmpty(e.Type))
+		for _, elt := range e.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				v.AddField(i.expr(kv.Key), i.expr(kv.Value))
+				continue
+			}
+			v.AddElement(i.expr(elt))
+		}
+		if i.fset != nil && len(e.Elts) > 0 &&
+			i.fset.Position(e.Lbrace).Line != i.fset.Position(e.Rbrace).Line {
+			v.MultiLine()
+		}
+		return v
+	case *ast.FuncLit:
+		f := Function("")
+		i.fieldList(e.Type.Params, f.parameters)
+		i.fieldList(e.Type.Results, f.results)
+		f.body.append(i.stmtList(e.Body.List, e.Body.Lbrace, e.Body.Rbrace)...)
+		if f.body.length() == 0 {
+			f.body.append(String(""))
+		}
+		return f
+	case *ast.ParenExpr:
+		return concat("(", i.expr(e.X), ")")
+	case *ast.UnaryExpr:
+		return concat(e.Op.String(), i.expr(e.X))
+	case *ast.BinaryExpr:
+		return concat(i.expr(e.X), " ", e.Op.String(), " ", i.expr(e.Y))
+	case *ast.StarExpr:
+		return &ptrType{elem: i.expr(e.X)}
+	case *ast.IndexExpr:
+		return concat(i.expr(e.X), "[", i.expr(e.Index), "]")
+	case *ast.IndexListExpr:
+		args := make([]Node, 0, len(e.Indices))
+		for _, idx := range e.Indices {
+			args = append(args, i.expr(idx))
+		}
+		return &genericType{base: i.expr(e.X), args: args}
+	case *ast.SliceExpr:
+		n := concat(i.expr(e.X), "[", i.exprOrEmpty(e.Low), ":", i.exprOrEmpty(e.High))
+		if e.Slice3 {
+			n.Append(":", i.exprOrEmpty(e.Max))
+		}
+		return n.Append("]")
+	case *ast.TypeAssertExpr:
+		if e.Type == nil {
+			return concat(i.expr(e.X), ".(type)")
+		}
+		return concat(i.expr(e.X), ".(", i.expr(e.Type), ")")
+	case *ast.KeyValueExpr:
+		return concat(i.expr(e.Key), ": ", i.expr(e.Value))
+	case *ast.Ellipsis:
+		return concat("...", i.exprOrEmpty(e.Elt))
+	case *ast.ArrayType:
+		if e.Len == nil {
+			return &sliceType{elem: i.expr(e.Elt)}
+		}
+		return concat("[", i.expr(e.Len), "]", i.expr(e.Elt))
+	case *ast.MapType:
+		return &mapType{key: i.expr(e.Key), value: i.expr(e.Value)}
+	case *ast.ChanType:
+		dir := chanBoth
+		switch e.Dir {
+		case ast.RECV:
+			dir = chanRecv
+		case ast.SEND:
+			dir = chanSend
+		}
+		return &chanType{elem: i.expr(e.Value), dir: dir}
+	case *ast.FuncType:
+		return concat("func", i.signature(e))
+	case *ast.StructType:
+		g := newGroup("struct{", "}", "; ")
+		for _, fd := range e.Fields.List {
+			g.append(i.structField(fd))
+		}
+		return g
+	case *ast.InterfaceType:
+		g := newGroup("interface{", "}", "; ")
+		for _, m := range e.Methods.List {
+			if ft, ok := m.Type.(*ast.FuncType); ok && len(m.Names) > 0 {
+				g.append(concat(m.Names[0].Name, i.signature(ft)))
+				continue
+			}
+			g.append(i.expr(m.Type))
+		}
+		return g
+	}
+	return i.fail("unsupported expression %T", e)
+}
+
+func (i *ASTImporter) callExpr(e *ast.CallExpr) Node {
+	args := make([]interface{}, 0, len(e.Args))
+	for idx, arg := range e.Args {
+		if idx == len(e.Args)-1 && e.Ellipsis.IsValid() {
+			args = append(args, concat(i.expr(arg), "..."))
+			continue
+		}
+		args = append(args, i.expr(arg))
+	}
+
+	switch fn := e.Fun.(type) {
+	case *ast.Ident:
+		return Call(fn.Name).AddParameter(args...)
+	case *ast.SelectorExpr:
+		if pkg := i.packageRef(fn.X); pkg != nil {
+			return pkg.Call(fn.Sel.Name, args...)
+		}
+		c := Call(fn.Sel.Name).AddParameter(args...)
+		c.owner = i.expr(fn.X)
+		return c
+	}
+	// Calls like `fn()()` or `(*T).Method(v)` don't have a name.
+	return concat(i.expr(e.Fun), newListGroup("(", ")", ", ").append(args...))
+}
+
+// signature renders parameters and results of a func type.
+func (i *ASTImporter) signature(ft *ast.FuncType) Node {
+	params := newListGroup("(", ")", ", ")
+	i.fieldList(ft.Params, params)
+	n := concat(params)
+	if ft.Results != nil && len(ft.Results.List) > 0 {
+		results := newGroup(" (", ")", ", ")
+		i.fieldList(ft.Results, results)
+		n.Append(results)
+	}
+	return n
+}
@@ -0,0 +1,298 @@
+package gg
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const astImportSource = `package source
+
+import (
+	"context"
+	"fmt"
+	errs "github.com/pkg/errors"
+)
+
+// Pair holds two values.
+type Pair[K comparable, V any] struct {
+	// Key is the key.
+	Key   K ` + "`json:\"key\"`" + `
+	Value V // trailing
+}
+
+type Getter interface {
+	fmt.Stringer
+	Get(ctx context.Context, keys ...string) (string, error)
+}
+
+const (
+	A = iota
+	B
+)
+
+var defaultTimeout, other int = 1, 2
+
+// Process does things.
+func (p *Pair[K, V]) Process(ctx context.Context, items []string) (n int, err error) {
+	// count items
+	for i, item := range items {
+		if item == "" {
+			continue
+		} else if i > 10 {
+			break
+		} else {
+			n++
+		}
+	}
+	switch v := any(p.Value).(type) {
+	case fmt.Stringer:
+		_ = v.String()
+	default:
+	}
+	m := map[string]int{
+		"a": 1,
+		"b": 2,
+	}
+	defer func() {
+		_ = recover()
+	}()
+	fn := func(x int) int { return x * 2 }
+	_ = fn(len(m))
+	if err := ctx.Err(); err != nil {
+		return 0, errs.Wrap(err, fmt.Sprintf("%d", n))
+	}
+	return n, nil
+}
+`
+
+func TestASTImporter_File(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "source.go", astImportSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen := New()
+	gen.SetPackage("target")
+	if err := NewASTImporter(gen, fset, file).File(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := format.Source(gen.Bytes())
+	if err != nil {
+		t.Fatalf("generated code is invalid: %v\n%s", err, gen.String())
+	}
+
+	expected := strings.Replace(astImportSource, "package source", "package target", 1)
+	expected = strings.Replace(expected, `	"fmt"
+	errs`, `	"fmt"
+
+	errs`, 1)
+	expected = strings.Replace(expected, "fn := func(x int) int { return x * 2 }", `fn := func(x int) int {
+		return x * 2
+	}`, 1)
+	expected = strings.Replace(expected, "	default:\n", "	default:\n\n", 1)
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestASTImporter_PackageRefs(t *testing.T) {
+	src := `package source
+
+import (
+	"strings"
+	errs "github.com/pkg/errors"
+)
+
+func Check(s string) error {
+	strings := strings.TrimSpace(s)
+	return errs.New(strings)
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "source.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen := New()
+	gen.SetPackage("target")
+	gen.PAlias("errors", "errs")
+
+	fn, err := NewASTImporter(gen, fset, file).FuncDecl(file.Decls[1].(*ast.FuncDecl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.Body().Append(fn)
+
+	output := gen.String()
+
+	// The alias is taken by another package in the target, so it must be renamed.
+	if !strings.Contains(output, `errs "errors"`) || !strings.Contains(output, `"github.com/pkg/errors"`) {
+		t.Errorf("Expected renamed import, got:\n%s", output)
+	}
+	if !strings.Contains(output, "return errors.New(strings)") {
+		t.Errorf("Expected renamed selector, got:\n%s", output)
+	}
+	// The local variable shadows the package and must not be rewritten.
+	if !strings.Contains(output, "strings := strings.TrimSpace(s)") {
+		t.Errorf("Expected package call, got:\n%s", output)
+	}
+}
+
+func TestASTImporter_Expr(t *testing.T) {
+	gen := New()
+	im := NewASTImporter(gen, nil, nil)
+
+	expr, err := parser.ParseExpr(`[]map[string]*T{{"a": nil}}[0]["a"].Field[1:2:3]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := im.Expr(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := pool.Get()
+	defer buf.Free()
+	n.render(buf)
+
+	compareAST(t, `[]map[string]*T{{"a": nil}}[0]["a"].Field[1:2:3]`, buf.String())
+
+	if _, err := im.Expr(&ast.BadExpr{}); err == nil {
+		t.Error("Expected error for bad expression")
+	}
+}
+
+func TestASTImporter_UnusedImports(t *testing.T) {
+	src := `package source
+
+import (
+	"fmt"
+	"strings"
+)
+
+func Upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+func Print(s string) {
+	fmt.Println(s)
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "source.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen := New()
+	gen.SetPackage("target")
+	fn, err := NewASTImporter(gen, fset, file).FuncDecl(file.Decls[1].(*ast.FuncDecl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.Body().Append(fn)
+
+	output := gen.String()
+	if !strings.Contains(output, `"strings"`) || strings.Contains(output, `"fmt"`) {
+		t.Errorf("Expected only the used import, got:\n%s", output)
+	}
+}
+
+func TestASTImporter_TrailingComments(t *testing.T) {
+	src := `package source
+
+func Sum(a, b int) int {
+	n := a + b // the sum
+	// of both
+	return n /* done */
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "source.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen := New()
+	gen.SetPackage("source")
+	if err := NewASTImporter(gen, fset, file).File(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := format.Source(gen.Bytes())
+	if err != nil {
+		t.Fatalf("generated code is invalid: %v\n%s", err, gen.String())
+	}
+	if string(out) != src {
+		t.Errorf("Expected:\n%s\ngot:\n%s", src, out)
+	}
+}
+
+func TestASTImporter_LineComments(t *testing.T) {
+	src := `package source
+
+func Sign(n int) int {
+	if n < 0 {
+		return -1 // negative
+	}
+	switch n {
+	case 0:
+		return 0 // zero
+	// no sign
+	default:
+		n = 1 // positive
+	}
+	return n // one
+}
+
+func Done() {
+	return // done
+}
+
+func Empty() {
+	// nothing
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "source.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen := New()
+	gen.SetPackage("source")
+	if err := NewASTImporter(gen, fset, file).File(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := format.Source(gen.Bytes())
+	if err != nil {
+		t.Fatalf("generated code is invalid: %v\n%s", err, gen.String())
+	}
+	if string(out) != src {
+		t.Errorf("Expected:\n%s\ngot:\n%s", src, out)
+	}
+}
+
+func TestASTImporter_RepoFiles(t *testing.T) {
+	for _, name := range []string{"gen.go", "walk.go", "ast_import.go"} {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen := New()
+		gen.SetPackage(file.Name.Name)
+		if err := NewASTImporter(gen, fset, file).File(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := format.Source(gen.Bytes()); err != nil {
+			t.Errorf("%s: generated code is invalid: %v", name, err)
+		}
+	}
+}
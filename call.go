@@ -15,7 +15,7 @@ type icall struct {
 func Call(name string) *icall {
 	ic := &icall{
 		name:  name,
-		items: newGroup("(", ")", ","),
+		items: newListGroup("(", ")", ","),
 		calls: newGroup("", "", "."),
 	}
 	return ic
@@ -49,6 +49,22 @@ func (i *icall) AddCall(name string, params ...interface{}) *icall {
 	return i
 }
 
+// MultiLine renders the arguments one per line with trailing commas.
+//
+// Example:
+//
+//	Call("fmt.Printf").AddParameter(Lit("%s %d"), "name", "age").MultiLine()
+//	// =>
+//	// fmt.Printf(
+//	//   "%s %d",
+//	//   name,
+//	//   age,
+//	// )
+func (i *icall) MultiLine() *icall {
+	i.items.multiLine = true
+	return i
+}
+
 // String returns the string representation of the call.
 func (i *icall) String() string {
 	buf := pool.Get()
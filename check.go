@@ -0,0 +1,310 @@
+package gg
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Diagnostic is an error found by type checking the generated code.
+type Diagnostic struct {
+	// Pos is the position in the generated file.
+	Pos token.Position
+	Msg string
+	// Nodes are the nodes which rendered the position, from the innermost
+	// to the top-level declaration. It is empty if the position is out of
+	// the body, like in the import block.
+	Nodes []Node
+}
+
+func (d Diagnostic) String() string {
+	return d.Pos.String() + ": " + d.Msg
+}
+
+// TypeCheckError is returned by TypeCheck when the generated code doesn't
+// compile.
+type TypeCheckError struct {
+	Package     string
+	Diagnostics []Diagnostic
+}
+
+func (e *TypeCheckError) Error() string {
+	msgs := make([]string, 0, len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		msgs = append(msgs, d.String())
+	}
+	return fmt.Sprintf("package %s: %s", e.Package, strings.Join(msgs, "; "))
+}
+
+// nodeSpan is the output range of a rendered node, which is an item of the
+// group.
+type nodeSpan struct {
+	node       Node
+	group      *Group
+	start, end int
+}
+
+// renderItem renders an item of a group, and records its output range if w
+// is a render context which tracks the nodes.
+func renderItem(w io.Writer, g *Group, n Node) {
+	ctx, ok := w.(*RenderContext)
+	if !ok || ctx.spans == nil {
+		n.render(w)
+		return
+	}
+	start := ctx.offset
+	n.render(w)
+	*ctx.spans = append(*ctx.spans, nodeSpan{node: n, group: g, start: start, end: ctx.offset})
+}
+
+// TypeCheck parses the generated code and type checks it with go/types, so
+// code which doesn't compile is found before it is written. Language
+// features newer than the version set by SetGoVersion are reported too,
+// like range over int before go1.22 or min and max before go1.21. Imported
+// packages are loaded from source, the standard library from GOROOT and
+// other packages from the module of the working directory.
+//
+// It returns a *TypeCheckError with the errors mapped back to the nodes
+// which rendered them.
+//
+// Example:
+//
+//	err := gen.TypeCheck()
+//	var tcErr *gg.TypeCheckError
+//	if errors.As(err, &tcErr) {
+//		for _, d := range tcErr.Diagnostics {
+//			decl := d.Nodes[len(d.Nodes)-1]
+//			fmt.Println(d.Pos, d.Msg, gg.DeclName(decl))
+//		}
+//	}
+func (g *Generator) TypeCheck() error {
+	path := g.importPath
+	if path == "" {
+		path = g.declaredPackage()
+	}
+	c := newTypeChecker()
+	_, err := c.check(path, []checkFile{{gen: g}})
+	return err
+}
+
+// TypeCheck type checks the files of the package together, see
+// Generator.TypeCheck. Test files and files excluded by their build
+// constraint are not checked.
+func (p *GoPackage) TypeCheck() error {
+	c := newTypeChecker()
+	path := p.importPath
+	if path == "" {
+		path = p.name
+	}
+	_, err := c.check(path, p.checkFiles())
+	return err
+}
+
+// TypeCheck type checks all packages of the module, see
+// Generator.TypeCheck. The packages of the module import each other from
+// memory, so they could be checked before they are written. The errors of
+// all packages are joined.
+func (m *Module) TypeCheck() error {
+	c := newTypeChecker()
+	for _, dir := range m.dirs {
+		c.local[m.importPath(dir)] = m.packages[dir]
+	}
+	var errs []error
+	for _, dir := range m.dirs {
+		if _, err := c.importLocal(m.importPath(dir)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkFile is a generated file to be type checked.
+type checkFile struct {
+	name string
+	gen  *Generator
+}
+
+// checkFiles returns the files of the package which are built.
+func (p *GoPackage) checkFiles() []checkFile {
+	var files []checkFile
+	for _, name := range p.names {
+		gen := p.files[name]
+		if strings.HasSuffix(name, "_test.go") || !matchBuildConstraint(gen.buildConstraint) {
+			continue
+		}
+		files = append(files, checkFile{name: name, gen: gen})
+	}
+	return files
+}
+
+// matchBuildConstraint reports whether the constraint is satisfied by the
+// default build context.
+func matchBuildConstraint(expr string) bool {
+	if expr == "" {
+		return true
+	}
+	x, err := constraint.Parse("//go:build " + expr)
+	if err != nil {
+		return true
+	}
+	ctx := build.Default
+	return x.Eval(func(tag string) bool {
+		return tag == ctx.GOOS || tag == ctx.GOARCH || tag == ctx.Compiler ||
+			(tag == "cgo" && ctx.CgoEnabled) ||
+			slices.Contains(ctx.BuildTags, tag) || slices.Contains(ctx.ReleaseTags, tag)
+	})
+}
+
+// typeChecker checks generated packages, and imports other packages from
+// memory or from source.
+type typeChecker struct {
+	fset   *token.FileSet
+	source types.ImporterFrom
+
+	// local maps import paths to the generated packages, and results to
+	// the checked ones, which are nil while being checked.
+	local   map[string]*GoPackage
+	results map[string]*types.Package
+	errs    map[string]error
+}
+
+func newTypeChecker() *typeChecker {
+	fset := token.NewFileSet()
+	return &typeChecker{
+		fset:    fset,
+		source:  importer.ForCompiler(fset, "source", nil).(types.ImporterFrom),
+		local:   make(map[string]*GoPackage),
+		results: make(map[string]*types.Package),
+		errs:    make(map[string]error),
+	}
+}
+
+// Import implements types.Importer.
+func (c *typeChecker) Import(path string) (*types.Package, error) {
+	return c.ImportFrom(path, ".", 0)
+}
+
+// ImportFrom implements types.ImporterFrom.
+func (c *typeChecker) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if _, ok := c.local[path]; ok {
+		pkg, err := c.importLocal(path)
+		if pkg == nil {
+			return nil, err
+		}
+		// Errors of the imported package are reported by its own check.
+		return pkg, nil
+	}
+	return c.source.ImportFrom(path, dir, mode)
+}
+
+// importLocal checks a generated package once.
+func (c *typeChecker) importLocal(path string) (*types.Package, error) {
+	if pkg, ok := c.results[path]; ok {
+		if pkg == nil {
+			return nil, fmt.Errorf("import cycle through %s", path)
+		}
+		return pkg, c.errs[path]
+	}
+	c.results[path] = nil
+	pkg, err := c.check(path, c.local[path].checkFiles())
+	c.results[path], c.errs[path] = pkg, err
+	return pkg, err
+}
+
+// check parses and type checks the files as the package of the path.
+func (c *typeChecker) check(path string, files []checkFile) (*types.Package, error) {
+	var (
+		asts   []*ast.File
+		diags  []Diagnostic
+		spans  = make(map[string][]nodeSpan, len(files))
+		starts = make(map[string]int, len(files))
+	)
+	report := func(pos token.Position, msg string) {
+		diags = append(diags, Diagnostic{
+			Pos:   pos,
+			Msg:   msg,
+			Nodes: nodesAt(spans[pos.Filename], pos.Offset-starts[pos.Filename]),
+		})
+	}
+
+	name, goVersion := path, ""
+	for _, f := range files {
+		if goVersion == "" {
+			goVersion = f.gen.typesGoVersion()
+		}
+		var s []nodeSpan
+		buf := pool.Get()
+		f.gen.renderFile(buf, &s)
+		src := buf.String()
+		buf.Free()
+		if f.gen.checksum {
+			// The stamp is checked too, so the positions match the file.
+			stamped := stampChecksum(src)
+			starts[f.name] = len(stamped) - len(src)
+			src = stamped
+		}
+		spans[f.name] = s
+
+		file, err := parser.ParseFile(c.fset, f.name, src, parser.ParseComments|parser.AllErrors)
+		var list scanner.ErrorList
+		if errors.As(err, &list) {
+			for _, e := range list {
+				report(e.Pos, e.Msg)
+			}
+		} else if err != nil {
+			report(token.Position{Filename: f.name}, err.Error())
+		}
+		if file != nil {
+			asts = append(asts, file)
+			name = file.Name.Name
+		}
+	}
+
+	conf := types.Config{
+		// Language features newer than the target version are errors.
+		GoVersion: goVersion,
+		Importer:  c,
+		Error: func(err error) {
+			var e types.Error
+			if errors.As(err, &e) {
+				report(e.Fset.Position(e.Pos), e.Msg)
+				return
+			}
+			report(token.Position{}, err.Error())
+		},
+	}
+	pkg, _ := conf.Check(path, c.fset, asts, nil)
+	if len(diags) > 0 {
+		return pkg, &TypeCheckError{Package: name, Diagnostics: diags}
+	}
+	return pkg, nil
+}
+
+// nodesAt returns the nodes which rendered the offset, the smallest first.
+func nodesAt(spans []nodeSpan, offset int) []Node {
+	var found []nodeSpan
+	for _, s := range spans {
+		if s.start <= offset && offset < s.end {
+			found = append(found, s)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].end-found[i].start < found[j].end-found[j].start
+	})
+	nodes := make([]Node, 0, len(found))
+	for _, s := range found {
+		nodes = append(nodes, s.node)
+	}
+	return nodes
+}
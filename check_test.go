@@ -0,0 +1,92 @@
+package gg
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerator_TypeCheck(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().NewFunction("main").AddBody(
+		gen.P("fmt").Call("Println", Lit("hello")),
+	)
+	if err := gen.TypeCheck(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	bad := Return(gen.P("strings").Call("ToUpper", "n"))
+	gen.Body().NewFunction("upper").
+		AddParameter("n", "int").
+		AddResult("", "string").
+		AddBody(bad)
+	gen.SetChecksum(true)
+
+	err := gen.TypeCheck()
+	var tcErr *TypeCheckError
+	if !errors.As(err, &tcErr) {
+		t.Fatalf("Expected a *TypeCheckError, got %v", err)
+	}
+	if len(tcErr.Diagnostics) != 1 {
+		t.Fatalf("Expected 1 diagnostic, got %v", tcErr.Diagnostics)
+	}
+	d := tcErr.Diagnostics[0]
+	if !slices.Contains(d.Nodes, Node(bad)) || DeclName(d.Nodes[len(d.Nodes)-1]) != "upper" {
+		t.Errorf("Expected the return statement of upper, got %v", d.Nodes)
+	}
+	if !strings.Contains(d.Msg, "cannot use n") {
+		t.Errorf("Unexpected message: %s", d.Msg)
+	}
+	lines := strings.Split(gen.String(), "\n")
+	if d.Pos.Line < 1 || !strings.Contains(lines[d.Pos.Line-1], "strings.ToUpper(n)") {
+		t.Errorf("Expected the position of the return statement, got %v", d.Pos)
+	}
+	if !strings.HasPrefix(err.Error(), "package main: ") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestGenerator_TypeCheck_Syntax(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().NewFunction("main").AddBody("x :=")
+
+	var tcErr *TypeCheckError
+	if err := gen.TypeCheck(); !errors.As(err, &tcErr) {
+		t.Fatalf("Expected a *TypeCheckError, got %v", err)
+	}
+	if len(tcErr.Diagnostics[0].Nodes) == 0 {
+		t.Errorf("Expected the syntax error to be mapped to a node")
+	}
+}
+
+func TestModule_TypeCheck(t *testing.T) {
+	mod := NewModule("github.com/example/svc")
+	models := mod.Package("models")
+	models.File("user.go").Body().NewStruct("User").AddField("Name", "string")
+	models.File("user_test.go").Body().AddString("var _ = undefined")
+
+	handler := mod.Package("handler").File("user.go")
+	handler.Body().NewFunction("Name").
+		AddParameter("u", models.Ref(handler).Ptr("User")).
+		AddResult("", "string").
+		AddBody(Return("u.Name"))
+	if err := mod.TypeCheck(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	handler.Body().NewFunction("Age").
+		AddParameter("u", models.Ref(handler).Ptr("User")).
+		AddResult("", "int").
+		AddBody(Return("u.Age"))
+	err := mod.TypeCheck()
+	var tcErr *TypeCheckError
+	if !errors.As(err, &tcErr) || tcErr.Package != "handler" {
+		t.Fatalf("Expected a *TypeCheckError of handler, got %v", err)
+	}
+	if d := tcErr.Diagnostics[0]; d.Pos.Filename != "user.go" || !strings.Contains(d.Msg, "u.Age undefined") {
+		t.Errorf("Unexpected diagnostic: %v", d)
+	}
+}
@@ -0,0 +1,120 @@
+package gg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const checksumPrefix = "// gg:checksum sha256:"
+
+var (
+	// ErrNoChecksum is returned by VerifyChecksum for a file without stamp.
+	ErrNoChecksum = errors.New("no checksum stamp")
+	// ErrChecksumMismatch is returned by VerifyChecksum for a file which has
+	// been changed after it was generated.
+	ErrChecksumMismatch = errors.New("checksum mismatch, the generated file has been edited")
+)
+
+// SetChecksum enables the checksum stamp, which is a hash of the generated
+// file written as its first line. VerifyFile reports files changed after
+// they were generated, the content of user regions (see UserRegion) is not
+// included in the hash.
+//
+// Example:
+//
+//	gen.SetChecksum(true)
+//	// => // gg:checksum sha256:9f86d081884c7d65...
+func (g *Generator) SetChecksum(enabled bool) *Generator {
+	g.checksum = enabled
+	return g
+}
+
+// stampChecksum adds the checksum line before the source.
+func stampChecksum(src string) string {
+	return checksumPrefix + contentHash(src) + "\n\n" + src
+}
+
+// contentHash returns the hash of the source, without the content of user
+// regions.
+func contentHash(src string) string {
+	h := sha256.New()
+	lines, spans, err := scanUserRegions([]byte(src))
+	if err != nil {
+		// Invalid regions are reported while writing, hash the whole source.
+		h.Write([]byte(src))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	last := 0
+	for _, s := range spans {
+		h.Write([]byte(strings.Join(lines[last:s.start], "")))
+		last = s.end
+	}
+	h.Write([]byte(strings.Join(lines[last:], "")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyChecksum checks the checksum stamp of a generated source. It returns
+// ErrNoChecksum if the source has no stamp, and ErrChecksumMismatch if the
+// source has been changed.
+func VerifyChecksum(src []byte) error {
+	text := string(src)
+	line, rest, _ := strings.Cut(text, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	if !strings.HasPrefix(line, checksumPrefix) {
+		return ErrNoChecksum
+	}
+	rest = strings.TrimPrefix(rest, "\n")
+	if _, _, err := scanUserRegions([]byte(rest)); err != nil {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, err)
+	}
+	if contentHash(rest) != strings.TrimPrefix(line, checksumPrefix) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// VerifyFile checks the checksum stamp of a generated file, see
+// VerifyChecksum.
+func VerifyFile(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read file %s: %s", path, err)
+	}
+	if err := VerifyChecksum(src); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// VerifyDir checks all stamped .go files in the directory tree, files
+// without stamp are skipped. It returns the errors of the files which have
+// been changed, so CI could reject manual edits of generated code.
+//
+// Example:
+//
+//	failed, err := gg.VerifyDir(".")
+//	for _, err := range failed {
+//		fmt.Println(err) // models/user.go: checksum mismatch, ...
+//	}
+func VerifyDir(root string) ([]error, error) {
+	var failed []error
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		if err := VerifyFile(path); err != nil && !errors.Is(err, ErrNoChecksum) {
+			failed = append(failed, err)
+		}
+		return nil
+	})
+	return failed, err
+}
@@ -0,0 +1,104 @@
+package gg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newChecksumGenerator() *Generator {
+	gen := New()
+	gen.SetPackage("models")
+	gen.SetHeader("Code generated by gg. DO NOT EDIT.")
+	gen.SetChecksum(true)
+	gen.Body().NewFunction("Validate").
+		AddResult("", "error").
+		AddBody(UserRegion("validate", Return("nil")))
+	return gen
+}
+
+func TestChecksum_Stamp(t *testing.T) {
+	output := newChecksumGenerator().String()
+	first, rest, _ := strings.Cut(output, "\n")
+	if !strings.HasPrefix(first, "// gg:checksum sha256:") || len(first) != len("// gg:checksum sha256:")+64 {
+		t.Errorf("Expected checksum stamp as first line, got:\n%s", output)
+	}
+	if !strings.HasPrefix(rest, "\n// Code generated by gg. DO NOT EDIT.\npackage models") {
+		t.Errorf("Expected the header after the stamp, got:\n%s", output)
+	}
+	if err := VerifyChecksum([]byte(output)); err != nil {
+		t.Errorf("Expected valid checksum, got %v", err)
+	}
+
+	gen := newChecksumGenerator()
+	gen.SetChecksum(false)
+	if err := VerifyChecksum(gen.Bytes()); !errors.Is(err, ErrNoChecksum) {
+		t.Errorf("Expected ErrNoChecksum, got %v", err)
+	}
+}
+
+func TestChecksum_Verify(t *testing.T) {
+	output := newChecksumGenerator().String()
+
+	tests := []struct {
+		name string
+		edit func(string) string
+		err  error
+	}{
+		{"unchanged", func(s string) string { return s }, nil},
+		{"user region edited", func(s string) string {
+			return strings.Replace(s, "return nil\n", "return errors.New(\"invalid\")\n", 1)
+		}, nil},
+		{"code edited", func(s string) string {
+			return strings.Replace(s, "func Validate", "func Check", 1)
+		}, ErrChecksumMismatch},
+		{"stamp edited", func(s string) string {
+			return strings.Replace(s, "sha256:", "sha256:0", 1)
+		}, ErrChecksumMismatch},
+		{"marker removed", func(s string) string {
+			return strings.Replace(s, "// gg:user-end validate\n", "", 1)
+		}, ErrChecksumMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyChecksum([]byte(tt.edit(output)))
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestChecksum_VerifyDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := newChecksumGenerator().WriteFile(filepath.Join(dir, "a.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	edited := filepath.Join(dir, "sub", "b.go")
+	if err := newChecksumGenerator().WriteFile(edited); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(edited)
+	if err := os.WriteFile(edited, append(data, "\n// hand-written\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manual.go"), []byte("package models\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	failed, err := VerifyDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || !errors.Is(failed[0], ErrChecksumMismatch) || !strings.HasPrefix(failed[0].Error(), edited) {
+		t.Errorf("Expected only %s to fail, got %v", edited, failed)
+	}
+	if err := VerifyFile(filepath.Join(dir, "a.go")); err != nil {
+		t.Errorf("Expected a.go to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package gg
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// NodeCloner could be implemented by a CustomNode to support Clone. Custom
+// nodes which don't implement it are shared between the original and the
+// cloned tree.
+type NodeCloner interface {
+	CloneNode() CustomNode
+}
+
+// Clone returns a deep copy of the node tree, the copy could be changed or
+// merged without affecting the original. Package references are kept, use
+// Generator.Clone to copy a whole file with its imports.
+//
+// Example:
+//
+//	tmpl := gg.Function("Validate").AddResult("", "error")
+//	fn := gg.Clone(tmpl).WithReceiver("u", "*User")
+func Clone[T Node](node T) T {
+	return cloneNode(node, nil).(T)
+}
+
+// Clone returns a deep copy of the group.
+func (g *Group) Clone() *Group {
+	return Clone(g)
+}
+
+// Clone returns a deep copy of the generator. The copy has its own package
+// references, and all nodes in the copy refer to them.
+func (g *Generator) Clone() *Generator {
+	c := &Generator{
+		packageName:        g.packageName,
+		headerComment:      g.headerComment,
+		buildConstraint:    g.buildConstraint,
+		checksum:           g.checksum,
+		provenanceComments: g.provenanceComments,
+		lineWidth:          g.lineWidth,
+		goVersion:          g.goVersion,
+		importPath:         g.importPath,
+		packages:           make(map[string]*PackageRef, len(g.packages)),
+		aliasToPath:        maps.Clone(g.aliasToPath),
+		registeredPaths:    slices.Clone(g.registeredPaths),
+		sideImports:        maps.Clone(g.sideImports),
+	}
+
+	refs := make(map[*PackageRef]*PackageRef, len(g.packages)+1)
+	for importPath, pkg := range g.packages {
+		ref := &PackageRef{importPath: pkg.importPath, alias: pkg.alias, gen: c}
+		c.packages[importPath] = ref
+		refs[pkg] = ref
+	}
+	if g.self != nil {
+		c.self = &PackageRef{importPath: g.self.importPath, gen: c}
+		refs[g.self] = c.self
+	}
+
+	c.g = cloneNode(g.g, refs).(*Group)
+	if g.source != nil {
+		c.source = g.source.clone(g.g, c.g)
+	}
+	return c
+}
+
+// cloneNode copies the node and its children, package references in refs
+// are replaced.
+func cloneNode(node Node, refs map[*PackageRef]*PackageRef) Node {
+	var c Node
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *Generator:
+		return n.Clone()
+	case *qualifiedIdent:
+		cp := *n
+		if ref, ok := refs[n.pkg]; ok {
+			cp.pkg = ref
+		}
+		return &cp
+	case *istring:
+		cp := *n
+		return &cp
+	case *lit:
+		cp := *n
+		return &cp
+	case *ipackage:
+		cp := *n
+		return &cp
+	case *iany:
+		cp := *n
+		c = &cp
+	case *icustom:
+		cloner, ok := n.node.(NodeCloner)
+		if !ok {
+			return n
+		}
+		c = &icustom{node: cloner.CloneNode()}
+	case *Group:
+		cp := *n
+		cp.items = slices.Clone(n.items)
+		c = &cp
+	case *genericType:
+		cp := *n
+		cp.args = slices.Clone(n.args)
+		c = &cp
+	case *iswitch:
+		cp := *n
+		cp.cases = slices.Clone(n.cases)
+		c = &cp
+	case *multiNameField:
+		cp := *n
+		cp.names = slices.Clone(n.names)
+		c = &cp
+	case *sliceType:
+		cp := *n
+		c = &cp
+	case *ptrType:
+		cp := *n
+		c = &cp
+	case *mapType:
+		cp := *n
+		c = &cp
+	case *chanType:
+		cp := *n
+		c = &cp
+	case *ifunction:
+		cp := *n
+		c = &cp
+	case *istruct:
+		cp := *n
+		c = &cp
+	case *iinterface:
+		cp := *n
+		c = &cp
+	case *isignature:
+		cp := *n
+		c = &cp
+	case *ivar:
+		cp := *n
+		c = &cp
+	case *iconst:
+		cp := *n
+		c = &cp
+	case *iimport:
+		cp := *n
+		c = &cp
+	case *iif:
+		cp := *n
+		c = &cp
+	case *ifor:
+		cp := *n
+		c = &cp
+	case *icase:
+		cp := *n
+		c = &cp
+	case *ireturn:
+		cp := *n
+		c = &cp
+	case *idefer:
+		cp := *n
+		c = &cp
+	case *icall:
+		cp := *n
+		c = &cp
+	case *ivalue:
+		cp := *n
+		c = &cp
+	case *islice:
+		cp := *n
+		c = &cp
+	case *iarray:
+		cp := *n
+		c = &cp
+	case *itype:
+		cp := *n
+		c = &cp
+	case *ifield:
+		cp := *n
+		c = &cp
+	case *iregion:
+		cp := *n
+		c = &cp
+	case *irangeInt:
+		cp := *n
+		c = &cp
+	default:
+		panic(fmt.Errorf("gg: cannot clone %T", node))
+	}
+
+	// The copy still shares the children, replace them with their copies.
+	eachChild(c, func(child *Node) {
+		*child = cloneNode(*child, refs)
+	})
+	// The call sites of the items are kept for their copies.
+	if cp, ok := c.(*Group); ok && cp.sites != nil {
+		orig := node.(*Group)
+		cp.sites = make(map[Node]Provenance, len(orig.sites))
+		for i, item := range orig.items {
+			if site, ok := orig.sites[item]; ok {
+				cp.sites[cp.items[i]] = site
+			}
+		}
+	}
+	return c
+}
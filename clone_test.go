@@ -0,0 +1,71 @@
+package gg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClone_Node(t *testing.T) {
+	tmpl := Function("Validate").AddResult("", "error")
+	tmpl.AddBody(Return(String("nil")))
+
+	a := Clone(tmpl).WithReceiver("u", "*User")
+	b := Clone(tmpl).WithReceiver("o", "*Order")
+	a.AddBody(String("panic(1)"))
+
+	compareAST(t, "func Validate() (error) {\nreturn nil\n}", renderString(tmpl))
+	compareAST(t, "func (u *User) Validate() (error) {\nreturn nil\npanic(1)\n}", renderString(a))
+	compareAST(t, "func (o *Order) Validate() (error) {\nreturn nil\n}", renderString(b))
+}
+
+func TestClone_KeepsOmitWrap(t *testing.T) {
+	c := Const().AddField("A", Lit(1))
+	cp := Clone(c)
+	cp.AddField("B", Lit(2))
+
+	compareAST(t, "const A = 1", renderString(c))
+	compareAST(t, "const (\nA = 1\nB = 2\n)", renderString(cp))
+}
+
+func TestClone_Generator(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	models := gen.P("github.com/example/models")
+	gen.Body().NewVar().AddTypedField("u", models.Ptr("User"), "nil")
+
+	cp := gen.Clone()
+	cp.PAlias("github.com/example/models", "m")
+	cp.Body().AddString("// copy")
+
+	if output := gen.String(); !strings.Contains(output, "u *models.User") || strings.Contains(output, "// copy") {
+		t.Errorf("Original should be unchanged, got:\n%s", output)
+	}
+	if output := cp.String(); !strings.Contains(output, "u *m.User") || !strings.Contains(output, `m "github.com/example/models"`) {
+		t.Errorf("Copy should use its own package references, got:\n%s", output)
+	}
+}
+
+func TestMerge_Twice(t *testing.T) {
+	shared := New()
+	shared.SetPackage("main")
+	shared.Body().NewVar().AddTypedField("u", shared.P("github.com/example/models").Type("User"), "nil")
+
+	gen1 := New()
+	gen1.SetPackage("main")
+	gen1.P("github.com/other/models")
+	gen1.Merge(shared)
+
+	gen2 := New()
+	gen2.SetPackage("main")
+	gen2.Merge(shared)
+
+	if output := gen1.String(); !strings.Contains(output, "u models2.User") {
+		t.Errorf("Expected renamed reference in first target, got:\n%s", output)
+	}
+	if output := gen2.String(); !strings.Contains(output, "u models.User") {
+		t.Errorf("Expected original reference in second target, got:\n%s", output)
+	}
+	if output := shared.String(); !strings.Contains(output, "u models.User") {
+		t.Errorf("Merged generator should be unchanged, got:\n%s", output)
+	}
+}
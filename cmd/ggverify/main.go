@@ -0,0 +1,65 @@
+// Command ggverify reports generated files which have been edited after
+// they were generated with a checksum stamp, see Generator.SetChecksum.
+//
+// Usage:
+//
+//	ggverify [path ...]
+//
+// Directories are checked recursively, a trailing `/...` like in `./...` is
+// accepted too. Files without stamp are skipped.
+// It exits with status 1 if any file has been changed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/donutnomad/gg"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ggverify [path ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	failed := false
+	for _, path := range paths {
+		if path == "..." {
+			path = "."
+		}
+		path = strings.TrimSuffix(path, "/...")
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if !info.IsDir() {
+			if err := gg.VerifyFile(path); err != nil {
+				fmt.Println(err)
+				failed = true
+			}
+			continue
+		}
+		errs, err := gg.VerifyDir(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		for _, err := range errs {
+			fmt.Println(err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
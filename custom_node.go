@@ -0,0 +1,152 @@
+package gg
+
+import (
+	"bytes"
+	"io"
+)
+
+// CustomNode is the contract for node types defined outside of this package.
+// A CustomNode could be passed anywhere a node is accepted, like Group.Append
+// or Function.AddBody.
+//
+// Example:
+//
+//	type logCall struct{ msg string }
+//
+//	func (l *logCall) RenderNode(ctx *gg.RenderContext) {
+//		ctx.Render(ctx.Import("log/slog").Call("Info", gg.Lit(l.msg)))
+//	}
+//
+//	fn.AddBody(&logCall{msg: "started"})
+type CustomNode interface {
+	RenderNode(ctx *RenderContext)
+}
+
+// NodeContainer could be implemented by a CustomNode which holds other
+// nodes, so they are visible to Merge and tree traversal. fn is called with
+// a pointer to every child, a new node could be stored through it.
+//
+// Example:
+//
+//	func (w *wrapper) EachChild(fn func(child *gg.Node)) {
+//		fn(&w.inner)
+//	}
+type NodeContainer interface {
+	EachChild(fn func(child *Node))
+}
+
+// RenderContext is the writer passed to a CustomNode while rendering.
+type RenderContext struct {
+	w      io.Writer
+	gen    *Generator
+	indent int
+
+	// offset is the number of bytes written, and spans records the output
+	// range of rendered items while it is not nil, see TypeCheck.
+	offset int
+	spans  *[]nodeSpan
+
+	// col is the column of the unindented output, and measuring is set
+	// while a group is rendered to measure its length, see SetLineWidth.
+	col       int
+	measuring bool
+}
+
+// renderContext returns w as a *RenderContext, so the state of an outer
+// context is kept while rendering nested nodes.
+func renderContext(w io.Writer) *RenderContext {
+	if ctx, ok := w.(*RenderContext); ok {
+		return ctx
+	}
+	return &RenderContext{w: w}
+}
+
+// Write implements io.Writer.
+func (c *RenderContext) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.offset += n
+	if i := bytes.LastIndexByte(p[:n], '\n'); i >= 0 {
+		c.col = n - i - 1
+	} else {
+		c.col += n
+	}
+	return n, err
+}
+
+// WriteString writes a raw source text.
+func (c *RenderContext) WriteString(s string) {
+	writeString(c, s)
+}
+
+// Render renders a child node, which could be a Node, a CustomNode or a
+// string.
+func (c *RenderContext) Render(node any) {
+	parseNode(node).render(c)
+}
+
+// Generator returns the generator which renders the node, it is nil when
+// the node is rendered without a generator, like Group.String.
+func (c *RenderContext) Generator() *Generator {
+	return c.gen
+}
+
+// Indent returns the number of blocks enclosing the node.
+func (c *RenderContext) Indent() int {
+	return c.indent
+}
+
+// Import returns a PackageRef for the given import path. The package is
+// registered to the generator, so it is included in the import block even
+// if it is registered while rendering.
+func (c *RenderContext) Import(importPath string) *PackageRef {
+	if c.gen != nil {
+		return c.gen.P(importPath)
+	}
+	return &PackageRef{
+		importPath: importPath,
+		alias:      resolvePackageAlias(importPath, map[string]bool{}),
+	}
+}
+
+// enterBlock increases the indentation if w is a render context, and returns
+// a function to restore it.
+func enterBlock(w io.Writer) func() {
+	ctx, ok := w.(*RenderContext)
+	if !ok {
+		return func() {}
+	}
+	ctx.indent++
+	return func() { ctx.indent-- }
+}
+
+// icustom adapts a CustomNode to Node.
+type icustom struct {
+	node CustomNode
+}
+
+// Custom wraps a CustomNode into a Node. It's not required for Append and
+// other builder methods, which accept a CustomNode directly.
+func Custom(node CustomNode) Node {
+	return &icustom{node: node}
+}
+
+// AsCustom returns the CustomNode wrapped by n, like the nodes returned by
+// Cursor.Node while walking a tree.
+func AsCustom(n Node) (CustomNode, bool) {
+	if c, ok := n.(*icustom); ok {
+		return c.node, true
+	}
+	return nil, false
+}
+
+func (i *icustom) render(w io.Writer) {
+	i.node.RenderNode(renderContext(w))
+}
+
+// eachChild calls fn with the children of a custom node which implements
+// NodeContainer.
+func (i *icustom) eachChild(fn func(child *Node)) {
+	if c, ok := i.node.(NodeContainer); ok {
+		c.EachChild(fn)
+	}
+}
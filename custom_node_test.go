@@ -0,0 +1,78 @@
+package gg
+
+import (
+	"strings"
+	"testing"
+)
+
+type testLogCall struct {
+	msg    string
+	indent int
+}
+
+func (l *testLogCall) RenderNode(ctx *RenderContext) {
+	l.indent = ctx.Indent()
+	ctx.Render(ctx.Import("log/slog").Call("Info", Lit(l.msg)))
+}
+
+type testWrapper struct {
+	inner Node
+}
+
+func (w *testWrapper) RenderNode(ctx *RenderContext) {
+	ctx.WriteString("_ = ")
+	ctx.Render(w.inner)
+}
+
+func (w *testWrapper) EachChild(fn func(child *Node)) {
+	fn(&w.inner)
+}
+
+func TestCustomNode(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+
+	call := &testLogCall{msg: "started"}
+	fn := gen.Body().NewFunction("main")
+	fn.AddBody(If("true").AddBody(call))
+
+	output := gen.String()
+	expected := `package main
+
+import "log/slog"
+
+func main() {
+	if true {
+		slog.Info("started")
+	}
+}`
+	compareAST(t, expected, output)
+	if call.indent != 2 {
+		t.Errorf("Expected indent 2, got %d", call.indent)
+	}
+}
+
+func TestCustomNode_WithoutGenerator(t *testing.T) {
+	g := NewGroup()
+	g.Append(Custom(&testLogCall{msg: "x"}))
+
+	compareAST(t, `slog.Info("x")`, g.String())
+}
+
+func TestCustomNode_Merge(t *testing.T) {
+	gen1 := New()
+	gen1.SetPackage("main")
+	gen1.P("github.com/example/errors")
+
+	gen2 := New()
+	gen2.SetPackage("main")
+	errs := gen2.P("errors")
+	gen2.Body().Append(&testWrapper{inner: errs.Dot("ErrUnsupported")})
+
+	gen1.Merge(gen2)
+	output := gen1.String()
+
+	if !strings.Contains(output, "_ = errors2.ErrUnsupported") {
+		t.Errorf("Expected renamed reference inside custom node, got:\n%s", output)
+	}
+}
@@ -0,0 +1,344 @@
+package gg
+
+import (
+	"slices"
+	"strings"
+)
+
+// FindFunction returns the function with the given name, methods are not
+// included. Nested groups (like the groups created by Generator.NewGroup)
+// are searched too. It returns nil if there is no such function.
+func (g *Group) FindFunction(name string) *ifunction {
+	return findDecl(g, func(f *ifunction) bool {
+		return f.receiver == nil && f.name == name
+	})
+}
+
+// FindMethod returns the method of the receiver type, recv is the type name
+// without pointer and type parameters, like `User` for `func (u *User[T])`.
+// It returns nil if there is no such method.
+func (g *Group) FindMethod(recv, name string) *ifunction {
+	return findDecl(g, func(f *ifunction) bool {
+		return f.name == name && receiverType(f) == recv
+	})
+}
+
+// Methods returns all methods of the receiver type in declaration order.
+func (g *Group) Methods(recv string) []*ifunction {
+	var out []*ifunction
+	eachDecl(g, func(_ *Group, _ int, n Node) bool {
+		if f, ok := n.(*ifunction); ok && receiverType(f) == recv {
+			out = append(out, f)
+		}
+		return true
+	})
+	return out
+}
+
+// FindType returns the type declaration with the given name, which could be
+// a struct, an interface or a type definition. It returns nil if there is no
+// such type.
+func (g *Group) FindType(name string) Node {
+	return findDecl(g, func(n Node) bool {
+		return typeName(n) == name
+	})
+}
+
+// FindStruct returns the struct with the given name, so fields could be
+// added to it. It returns nil if there is no such struct.
+func (g *Group) FindStruct(name string) *istruct {
+	return findDecl(g, func(s *istruct) bool {
+		return s.name == name
+	})
+}
+
+// FindInterface returns the interface with the given name, so methods could
+// be added to it. It returns nil if there is no such interface.
+func (g *Group) FindInterface(name string) *iinterface {
+	return findDecl(g, func(i *iinterface) bool {
+		return i.name == name
+	})
+}
+
+// FindConst returns the const declaration which declares the name.
+func (g *Group) FindConst(name string) *iconst {
+	return findDecl(g, func(c *iconst) bool {
+		return slices.Contains(declaredNames(c.items), name)
+	})
+}
+
+// FindVar returns the var declaration which declares the name.
+func (g *Group) FindVar(name string) *ivar {
+	return findDecl(g, func(v *ivar) bool {
+		return slices.Contains(declaredNames(v.items), name)
+	})
+}
+
+// Remove removes the declaration together with the comments right before
+// it. It reports whether the node has been found.
+//
+// Example:
+//
+//	gen.Body().Remove(gen.Body().FindMethod("User", "Validate"))
+func (g *Group) Remove(node Node) bool {
+	parent, idx, ok := findItem(g, node)
+	if !ok {
+		return false
+	}
+	start := leadingComments(parent, idx)
+	parent.items = slices.Delete(parent.items, start, idx+1)
+	return true
+}
+
+// InsertBefore inserts nodes before the declaration, and before the comments
+// of the declaration. It reports whether the declaration has been found.
+func (g *Group) InsertBefore(mark Node, nodes ...any) bool {
+	parent, idx, ok := findItem(g, mark)
+	if !ok {
+		return false
+	}
+	idx = leadingComments(parent, idx)
+	parent.items = slices.Insert(parent.items, idx, parseNodes(nodes)...)
+	return true
+}
+
+// InsertAfter inserts nodes after the declaration. It reports whether the
+// declaration has been found.
+func (g *Group) InsertAfter(mark Node, nodes ...any) bool {
+	parent, idx, ok := findItem(g, mark)
+	if !ok {
+		return false
+	}
+	parent.items = slices.Insert(parent.items, idx+1, parseNodes(nodes)...)
+	return true
+}
+
+// SortDecls sorts the items of the group with a stable sort. The comments
+// and empty lines right before a declaration are moved together with it,
+// while the items after the last declaration are kept at the end.
+//
+// Example:
+//
+//	gen.Body().SortDecls(func(a, b gg.Node) bool {
+//		return gg.DeclName(a) < gg.DeclName(b)
+//	})
+func (g *Group) SortDecls(less func(a, b Node) bool) *Group {
+	type unit struct {
+		decl  Node
+		items []Node
+	}
+	var units []unit
+	start := 0
+	for i, item := range g.items {
+		if isTrivia(item) {
+			continue
+		}
+		units = append(units, unit{decl: item, items: g.items[start : i+1]})
+		start = i + 1
+	}
+	tail := g.items[start:]
+
+	slices.SortStableFunc(units, func(a, b unit) int {
+		switch {
+		case less(a.decl, b.decl):
+			return -1
+		case less(b.decl, a.decl):
+			return 1
+		}
+		return 0
+	})
+
+	items := make([]Node, 0, len(g.items))
+	for _, u := range units {
+		items = append(items, u.items...)
+	}
+	g.items = append(items, tail...)
+	return g
+}
+
+// SortByType sorts the declarations so that every type is followed by its
+// constructors (functions named `New` + type name) and then its methods.
+// Other declarations keep their relative order.
+func (g *Group) SortByType() *Group {
+	// Every declaration is anchored to the position of its type, the rank
+	// orders the declarations of the same type.
+	types := make(map[string]int)
+	for i, item := range g.items {
+		if name := typeName(item); name != "" {
+			if _, ok := types[name]; !ok {
+				types[name] = i
+			}
+		}
+	}
+	type key struct{ anchor, rank int }
+	keys := make(map[Node]key, len(g.items))
+	for i, item := range g.items {
+		k := key{anchor: i}
+		if f, ok := item.(*ifunction); ok {
+			name := receiverType(f)
+			rank := 2
+			if name == "" {
+				name, rank = strings.TrimPrefix(f.name, "New"), 1
+				if name == f.name {
+					name = ""
+				}
+			}
+			if anchor, ok := types[name]; ok {
+				k = key{anchor: anchor, rank: rank}
+			}
+		}
+		keys[item] = k
+	}
+	return g.SortDecls(func(a, b Node) bool {
+		ka, kb := keys[a], keys[b]
+		if ka.anchor != kb.anchor {
+			return ka.anchor < kb.anchor
+		}
+		return ka.rank < kb.rank
+	})
+}
+
+// DeclName returns the name of a declaration: the function name, `Type.Method`
+// for methods, the type name, or the first name of a const or var
+// declaration. It returns an empty string for other nodes.
+func DeclName(n Node) string {
+	switch v := n.(type) {
+	case *ifunction:
+		if recv := receiverType(v); recv != "" {
+			return recv + "." + v.name
+		}
+		return v.name
+	case *iconst:
+		if names := declaredNames(v.items); len(names) > 0 {
+			return names[0]
+		}
+	case *ivar:
+		if names := declaredNames(v.items); len(names) > 0 {
+			return names[0]
+		}
+	}
+	return typeName(n)
+}
+
+// isTrivia reports whether the item is an empty line or a comment, which
+// belongs to the next declaration.
+func isTrivia(n Node) bool {
+	s, ok := n.(*istring)
+	if !ok {
+		return false
+	}
+	return strings.TrimSpace(string(*s)) == "" || isComment(string(*s))
+}
+
+// leadingComments returns the index of the first comment which is right
+// before the item at idx.
+func leadingComments(g *Group, idx int) int {
+	for idx > 0 {
+		s, ok := g.items[idx-1].(*istring)
+		if !ok || !isComment(string(*s)) {
+			break
+		}
+		idx--
+	}
+	return idx
+}
+
+// eachDecl calls fn with every item of the group and of the nested plain
+// groups, until fn returns false.
+func eachDecl(g *Group, fn func(parent *Group, idx int, n Node) bool) bool {
+	for i, item := range g.items {
+		if sub, ok := item.(*Group); ok && sub.open == "" && sub.close == "" && sub.separator == "\n" {
+			if !eachDecl(sub, fn) {
+				return false
+			}
+			continue
+		}
+		if !fn(g, i, item) {
+			return false
+		}
+	}
+	return true
+}
+
+// findDecl returns the first declaration of type T which matches.
+func findDecl[T Node](g *Group, match func(T) bool) T {
+	var found T
+	eachDecl(g, func(_ *Group, _ int, n Node) bool {
+		if v, ok := n.(T); ok && match(v) {
+			found = v
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// findItem returns the group which directly holds the node, and its index.
+func findItem(g *Group, node Node) (*Group, int, bool) {
+	var parent *Group
+	idx := -1
+	eachDecl(g, func(p *Group, i int, n Node) bool {
+		if n == node {
+			parent, idx = p, i
+			return false
+		}
+		return true
+	})
+	return parent, idx, parent != nil
+}
+
+// typeName returns the name of a type declaration, or an empty string.
+func typeName(n Node) string {
+	switch v := n.(type) {
+	case *istruct:
+		return v.name
+	case *iinterface:
+		return v.name
+	case *itype:
+		return v.name
+	}
+	return ""
+}
+
+// receiverType returns the receiver type name of a method, without pointer
+// and type parameters.
+func receiverType(f *ifunction) string {
+	var typ Node = f.receiver
+	if r, ok := f.receiver.(*ifield); ok {
+		typ = r.value
+	}
+	if typ == nil {
+		return ""
+	}
+	name := strings.TrimPrefix(strings.TrimSpace(renderString(typ)), "*")
+	name, _, _ = strings.Cut(name, "[")
+	return strings.TrimSpace(name)
+}
+
+// declaredNames returns the names declared by the items of a const or var
+// declaration.
+func declaredNames(items *Group) []string {
+	var names []string
+	for _, item := range items.items {
+		var text string
+		switch v := item.(type) {
+		case *ifield:
+			text = renderString(v.name)
+		case *istring:
+			if isComment(string(*v)) {
+				continue
+			}
+			text, _, _ = strings.Cut(string(*v), "=")
+			if fields := strings.Fields(text); len(fields) > 0 && !strings.HasSuffix(fields[0], ",") {
+				// Drop the type of a spec like `a int`.
+				text = fields[0]
+			}
+		default:
+			continue
+		}
+		for _, name := range strings.Split(text, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	return names
+}
@@ -0,0 +1,131 @@
+package gg
+
+import (
+	"strings"
+	"testing"
+)
+
+func newDeclGenerator() *Generator {
+	gen := New()
+	gen.SetPackage("models")
+
+	body := gen.Body()
+	body.NewFunction("Validate").WithReceiver("u", "*User").AddResult("", "error").AddBody(Return(String("nil")))
+	body.AddLineComment("Version is the schema version.")
+	body.NewConst().AddField("Version", Lit(1))
+	body.NewStruct("Order").AddField("ID", "int64")
+	body.AddLineComment("NewUser creates a user.")
+	body.NewFunction("NewUser").AddResult("", "*User").AddBody(Return(String("&User{}")))
+	body.NewStruct("User").AddField("Name", "string")
+	body.NewFunction("Total").WithReceiver("o", "Order").AddResult("", "int").AddBody(Return(String("0")))
+	gen.NewGroup().NewVar().AddField("defaultUser, other", String("NewUser(), 1"))
+	return gen
+}
+
+func TestGroup_Find(t *testing.T) {
+	body := newDeclGenerator().Body()
+
+	if fn := body.FindFunction("NewUser"); fn == nil || fn.name != "NewUser" {
+		t.Errorf("Expected NewUser, got %v", fn)
+	}
+	if fn := body.FindFunction("Validate"); fn != nil {
+		t.Errorf("FindFunction should not return methods, got %v", fn)
+	}
+	if fn := body.FindMethod("User", "Validate"); fn == nil {
+		t.Error("Expected method User.Validate")
+	}
+	if ms := body.Methods("Order"); len(ms) != 1 || ms[0].name != "Total" {
+		t.Errorf("Expected one method of Order, got %v", ms)
+	}
+	if typ, ok := body.FindType("User").(*istruct); !ok || typ.name != "User" {
+		t.Errorf("Expected struct User, got %v", typ)
+	}
+	if body.FindType("Missing") != nil {
+		t.Error("Expected nil for missing type")
+	}
+	if s := body.FindStruct("User"); s == nil || s.name != "User" {
+		t.Errorf("Expected struct User, got %v", s)
+	}
+	if body.FindStruct("Order") == nil || body.FindInterface("User") != nil {
+		t.Error("Expected FindStruct and FindInterface to match the kind")
+	}
+	if body.FindConst("Version") == nil {
+		t.Error("Expected const Version")
+	}
+	if body.FindVar("other") == nil {
+		t.Error("Expected var in nested group")
+	}
+}
+
+func TestGroup_RemoveAndInsert(t *testing.T) {
+	gen := newDeclGenerator()
+	body := gen.Body()
+
+	if !body.Remove(body.FindConst("Version")) {
+		t.Fatal("Expected const to be removed")
+	}
+	if body.Remove(body.FindConst("Version")) {
+		t.Error("Expected second removal to fail")
+	}
+	order := body.FindType("Order")
+	body.InsertBefore(order, LineComment("Order is an order."))
+	body.InsertAfter(order, Type("OrderID", "int64"))
+	body.InsertAfter(body.FindVar("defaultUser"), Var().AddField("nested", Lit(true)))
+
+	output := gen.String()
+	if strings.Contains(output, "Version") {
+		t.Errorf("Expected const and its comment to be removed, got:\n%s", output)
+	}
+	expected := `// Order is an order.
+type Order struct {
+ID int64
+}
+type OrderID int64`
+	if !strings.Contains(cleanAST(output), cleanAST(expected)) {
+		t.Errorf("Expected inserted declarations, got:\n%s", output)
+	}
+	if !strings.Contains(cleanAST(output), cleanAST("var defaultUser, other = NewUser(), 1\nvar nested = true")) {
+		t.Errorf("Expected insertion into nested group, got:\n%s", output)
+	}
+}
+
+func TestGroup_SortByType(t *testing.T) {
+	body := newDeclGenerator().Body()
+	body.SortByType()
+
+	var names []string
+	for _, item := range body.items {
+		if name := DeclName(item); name != "" {
+			names = append(names, name)
+		}
+	}
+	expected := "Version,Order,Order.Total,User,NewUser,User.Validate"
+	if got := strings.Join(names, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	// Comments are moved with their declarations.
+	idx := 0
+	for i, item := range body.items {
+		if item == Node(body.FindFunction("NewUser")) {
+			idx = i
+		}
+	}
+	if s, ok := body.items[idx-1].(*istring); !ok || !strings.Contains(string(*s), "NewUser creates") {
+		t.Errorf("Expected the comment before NewUser, got %v", body.items[idx-1])
+	}
+}
+
+func TestGroup_SortDecls(t *testing.T) {
+	body := NewGroup()
+	body.NewFunction("b")
+	body.AddLineComment("a is first.")
+	body.NewFunction("a")
+	body.AddLineComment("trailing")
+
+	body.SortDecls(func(x, y Node) bool {
+		return DeclName(x) < DeclName(y)
+	})
+
+	compareAST(t, "// a is first.\nfunc a()\nfunc b()\n// trailing", body.String())
+}
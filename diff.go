@@ -0,0 +1,207 @@
+package gg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around a hunk.
+const diffContext = 3
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffLine struct {
+	kind diffKind
+	text string
+}
+
+// unifiedDiff returns the unified diff between two texts, or an empty string
+// if they are the same. An empty from name means that the file is new.
+func unifiedDiff(from, to string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+	lines := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	var out strings.Builder
+	if from == "" {
+		out.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&out, "--- %s\n", from)
+	}
+	fmt.Fprintf(&out, "+++ %s\n", to)
+
+	// Line numbers before every item, used for the hunk headers.
+	ai, bi := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	for i, l := range lines {
+		ai[i+1], bi[i+1] = ai[i], bi[i]
+		if l.kind != diffInsert {
+			ai[i+1]++
+		}
+		if l.kind != diffDelete {
+			bi[i+1]++
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].kind == diffEqual {
+			i++
+			continue
+		}
+		// Extend the hunk until the changes are more than two contexts apart.
+		start, end := max(i-diffContext, 0), i
+		for j := i; j < len(lines) && j-end <= 2*diffContext; j++ {
+			if lines[j].kind != diffEqual {
+				end = j + 1
+			}
+		}
+		end = min(end+diffContext, len(lines))
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(ai[start], ai[end]), hunkRange(bi[start], bi[end]))
+		for _, l := range lines[start:end] {
+			out.WriteString(string(" -+"[l.kind]))
+			out.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the lines [start, end) of a hunk header.
+func hunkRange(start, end int) string {
+	switch n := end - start; n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
+
+// splitLines splits the text after every newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script from a to b with the
+// linear space variant of the Myers algorithm.
+func diffLines(a, b []string) []diffLine {
+	var out []diffLine
+	diffRange(a, b, &out)
+	return out
+}
+
+// diffRange appends the edit script from a to b to out. The middle snake
+// of the edit script splits it into two smaller ones, so the memory is
+// linear in the number of lines.
+func diffRange(a, b []string, out *[]diffLine) {
+	// Common prefix and suffix are trimmed first, generated files usually
+	// change in a few places only.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, l := range a[:prefix] {
+		*out = append(*out, diffLine{diffEqual, l})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(midA) == 0:
+		for _, l := range midB {
+			*out = append(*out, diffLine{diffInsert, l})
+		}
+	case len(midB) == 0:
+		for _, l := range midA {
+			*out = append(*out, diffLine{diffDelete, l})
+		}
+	default:
+		// Both ends differ, so the split is neither the start nor the end.
+		x, y := middleSnake(midA, midB)
+		diffRange(midA[:x], midB[:y], out)
+		diffRange(midA[x:], midB[y:], out)
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		*out = append(*out, diffLine{diffEqual, l})
+	}
+}
+
+// middleSnake returns a point on a shortest edit script from a to b, which
+// is found by searching from both ends until the paths overlap.
+func middleSnake(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward holds the furthest x on every diagonal x-y from the start,
+	// backward the furthest x on every diagonal from the end, in the
+	// coordinates of the reversed lines.
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			// The backward search has done d-1 steps.
+			if rk := delta - k; odd && rk >= -(d-1) && rk <= d-1 && x >= n-backward[offset+rk] {
+				return startX, startY
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if fk := delta - k; !odd && fk >= -d && fk <= d && n-x <= forward[offset+fk] {
+				return n - startX, m - startY
+			}
+		}
+	}
+	// Not reached, the paths overlap within maxD steps.
+	return n, m
+}
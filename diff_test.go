@@ -0,0 +1,151 @@
+package gg
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i))
+	}
+	old := strings.Join(lines, "")
+	lines[1] = "changed 2\n"
+	lines = append(lines[:10], lines[11:]...)
+	lines = append(lines, "line 21")
+
+	expected := `--- a.go
++++ a.go
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++changed 2
+ line 3
+ line 4
+ line 5
+@@ -8,7 +8,6 @@
+ line 8
+ line 9
+ line 10
+-line 11
+ line 12
+ line 13
+ line 14
+@@ -18,3 +17,4 @@
+ line 18
+ line 19
+ line 20
++line 21
+\ No newline at end of file
+`
+	if got := unifiedDiff("a.go", "a.go", []byte(old), []byte(strings.Join(lines, ""))); got != expected {
+		t.Errorf("Unexpected diff:\n%s", got)
+	}
+
+	if got := unifiedDiff("a.go", "a.go", []byte(old), []byte(old)); got != "" {
+		t.Errorf("Expected no diff, got:\n%s", got)
+	}
+
+	expected = `--- /dev/null
++++ new.go
+@@ -0,0 +1,2 @@
++package main
++
+`
+	if got := unifiedDiff("", "new.go", nil, []byte("package main\n\n")); got != expected {
+		t.Errorf("Unexpected diff for a new file:\n%s", got)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"a", "b", "c", "a", "b", "b", "a"}
+	b := []string{"c", "b", "a", "b", "a", "c"}
+	edits := diffLines(a, b)
+
+	var from, to []string
+	deleted := 0
+	for _, l := range edits {
+		switch l.kind {
+		case diffEqual:
+			from, to = append(from, l.text), append(to, l.text)
+		case diffDelete:
+			from = append(from, l.text)
+			deleted++
+		case diffInsert:
+			to = append(to, l.text)
+		}
+	}
+	if strings.Join(from, "") != strings.Join(a, "") || strings.Join(to, "") != strings.Join(b, "") {
+		t.Errorf("Edit script doesn't convert a to b: %v", edits)
+	}
+	// The shortest edit script has 5 edits, 3 of them deletions.
+	if len(edits) != 9 || deleted != 3 {
+		t.Errorf("Expected the shortest edit script, got %v", edits)
+	}
+}
+
+func TestDiffLines_Shortest(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	lines := func() []string {
+		l := make([]string, rnd.Intn(30))
+		for i := range l {
+			l[i] = string(rune('a' + rnd.Intn(3)))
+		}
+		return l
+	}
+	for i := 0; i < 500; i++ {
+		a, b := lines(), lines()
+		var from, to []string
+		edits := 0
+		for _, l := range diffLines(a, b) {
+			if l.kind != diffInsert {
+				from = append(from, l.text)
+			}
+			if l.kind != diffDelete {
+				to = append(to, l.text)
+			}
+			if l.kind != diffEqual {
+				edits++
+			}
+		}
+		if !slices.Equal(from, a) || !slices.Equal(to, b) {
+			t.Fatalf("Edit script doesn't convert %q to %q", a, b)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("Expected %d edits from %q to %q, got %d", want, a, b, edits)
+		}
+	}
+}
+
+func TestDiffLines_Rewrite(t *testing.T) {
+	a := make([]string, 5000)
+	b := make([]string, 5000)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d\n", i)
+		b[i] = fmt.Sprintf("new %d\n", i)
+	}
+	if edits := diffLines(a, b); len(edits) != 10000 {
+		t.Errorf("Expected 10000 edits, got %d", len(edits))
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence.
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
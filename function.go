@@ -5,6 +5,7 @@ import "io"
 type ifunction struct {
 	name       string
 	receiver   Node
+	typeParams *Group
 	parameters *Group
 	results    *Group
 	body       *Group
@@ -31,16 +32,17 @@ type ifunction struct {
 func Function(name string) *ifunction {
 	i := &ifunction{
 		name:       name,
-		parameters: newGroup("(", ")", ","),
-		results:    newGroup("(", ")", ","),
+		typeParams: newTypeParams(),
+		parameters: newListGroup("(", ")", ","),
+		results:    newListGroup("(", ")", ","),
 		body:       newGroup("{\n", "}", "\n"),
 	}
 	// Enable field merging for parameters and results
 	i.parameters.mergeFields = true
 	i.results.mergeFields = true
 	// We should omit the `()` if result is empty
-	i.results.omitWrapIf = func() bool {
-		l := i.results.length()
+	i.results.omitWrapIf = func(g *Group) bool {
+		l := g.length()
 		if l == 0 {
 			// There is no result fields, we can omit `()` safely.
 			return true
@@ -66,11 +68,11 @@ func (i *ifunction) render(w io.Writer) {
 	// Render function name
 	writeString(w, i.name)
 
-	// Render parameters
-	i.parameters.render(w)
+	// Render type parameters
+	i.typeParams.render(w)
 
-	// Render results
-	i.results.render(w)
+	// Render parameters and results
+	renderSignature(w, i.parameters, i.results)
 
 	// Only render body while there is a body or a call.
 	//
@@ -96,6 +98,12 @@ func (i *ifunction) WithCall(params ...interface{}) *ifunction {
 	return i
 }
 
+// AddTypeParameter adds a type parameter, like `func Map[T, U any]()`.
+func (i *ifunction) AddTypeParameter(name, constraint interface{}) *ifunction {
+	i.typeParams.append(field(name, constraint, " "))
+	return i
+}
+
 func (i *ifunction) AddParameter(name, typ interface{}) *ifunction {
 	i.parameters.append(field(name, typ, " "))
 	return i
@@ -116,6 +124,21 @@ func (i *ifunction) AddResult(name, typ interface{}) *ifunction {
 	return i
 }
 
+// MultiLine renders the parameters one per line with trailing commas.
+//
+// Example:
+//
+//	Function("New").AddParameter("ctx", "context.Context").AddParameter("db", "*sql.DB").MultiLine()
+//	// =>
+//	// func New(
+//	//   ctx context.Context,
+//	//   db *sql.DB,
+//	// )
+func (i *ifunction) MultiLine() *ifunction {
+	i.parameters.multiLine = true
+	return i
+}
+
 func (i *ifunction) AddBody(node ...interface{}) *ifunction {
 	i.body.append(node...)
 	return i
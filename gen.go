@@ -1,10 +1,12 @@
 package gg
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"sort"
+	"strings"
 )
 
 // Generator is the main entry point for code generation.
@@ -17,9 +19,24 @@ type Generator struct {
 	packages        map[string]*PackageRef // importPath -> PackageRef
 	aliasToPath     map[string]string      // alias -> importPath (for conflict detection)
 	registeredPaths []string               // ordered list of import paths for deterministic output
+	importPath      string                 // import path of the generated package itself
+	self            *PackageRef            // unqualified reference to the generated package
+	sideImports     map[string]string      // importPath -> "_" or "." for blank and dot imports
 
 	// Header comment (appears before package declaration)
 	headerComment string
+	// Build constraint expression, rendered as a //go:build line
+	buildConstraint string
+	// Stamp a checksum of the content as the first line
+	checksum bool
+	// Comment the lines with the call sites of their nodes
+	provenanceComments bool
+	// Maximum line width before lists are wrapped, 0 for no limit
+	lineWidth int
+	// Targeted Go version like 1.21, empty for no specific version
+	goVersion string
+	// Go file the generator was loaded from, see Load
+	source *sourceFile
 }
 
 // New will create a new generator which hold the group reference.
@@ -31,12 +48,54 @@ func New() *Generator {
 	}
 }
 
-// SetPackage sets the package name for the generated file.
+// SetPackage sets the package name for the generated file. An external test
+// package, like `models_test`, imports the package under test, so the import
+// path set by SetImportPath is cleared, and the references created before
+// are qualified and imported.
 func (g *Generator) SetPackage(name string) *Generator {
 	g.packageName = name
+	if strings.HasSuffix(name, "_test") && g.importPath != "" {
+		self, importPath := g.self, g.importPath
+		g.importPath, g.self = "", nil
+		if self != nil {
+			self.alias = g.P(importPath).alias
+			g.packages[importPath] = self
+		}
+	}
 	return g
 }
 
+// SetImportPath sets the import path of the package being generated.
+// References to this package through P or PAlias are not qualified and not imported,
+// including the references created before.
+//
+// Example:
+//
+//	gen.SetImportPath("github.com/example/models")
+//	gen.P("github.com/example/models").Type("User") // => User
+func (g *Generator) SetImportPath(importPath string) *Generator {
+	g.importPath = importPath
+	g.self = nil
+	if pkg, ok := g.packages[importPath]; ok {
+		delete(g.packages, importPath)
+		delete(g.aliasToPath, pkg.alias)
+		for i, p := range g.registeredPaths {
+			if p == importPath {
+				g.registeredPaths = append(g.registeredPaths[:i:i], g.registeredPaths[i+1:]...)
+				break
+			}
+		}
+		pkg.alias = ""
+		g.self = pkg
+	}
+	return g
+}
+
+// ImportPath returns the import path set by SetImportPath.
+func (g *Generator) ImportPath() string {
+	return g.importPath
+}
+
 // SetHeader sets a header comment that appears before the package declaration.
 // Typically used for "Code generated by X. DO NOT EDIT." comments.
 func (g *Generator) SetHeader(format string, args ...any) *Generator {
@@ -44,6 +103,68 @@ func (g *Generator) SetHeader(format string, args ...any) *Generator {
 	return g
 }
 
+// Header returns the header comment set by SetHeader.
+func (g *Generator) Header() string {
+	return g.headerComment
+}
+
+// SetLineWidth sets the maximum line width, 0 means no limit. Call
+// arguments, parameters, results and composite literals which would exceed
+// it are rendered one item per line with trailing commas. Indentation tabs
+// count as four columns.
+//
+// Example:
+//
+//	gen.SetLineWidth(100)
+//	// => db.Query(
+//	//	ctx,
+//	//	"SELECT id, name, email FROM users WHERE org_id = ? AND deleted_at IS NULL",
+//	//	orgID,
+//	// )
+func (g *Generator) SetLineWidth(width int) *Generator {
+	g.lineWidth = width
+	return g
+}
+
+// SetBuildConstraint sets the build constraint of the generated file, which
+// is rendered as a `//go:build` line at the top of the file.
+//
+// Example:
+//
+//	gen.SetBuildConstraint("linux && !cgo")
+func (g *Generator) SetBuildConstraint(expr string) *Generator {
+	g.buildConstraint = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(expr), "//go:build"))
+	return g
+}
+
+// BuildConstraint returns the build constraint set by SetBuildConstraint.
+func (g *Generator) BuildConstraint() string {
+	return g.buildConstraint
+}
+
+// ImportBlank adds a blank import, like `_ "embed"`, which is imported only
+// for its side effects. It's omitted if the package is referenced through P.
+func (g *Generator) ImportBlank(importPath string) *Generator {
+	if g.sideImports[importPath] != "." {
+		g.addSideImport(importPath, "_")
+	}
+	return g
+}
+
+// ImportDot adds a dot import, like `. "math"`, so the exported names of the
+// package could be used without qualifier.
+func (g *Generator) ImportDot(importPath string) *Generator {
+	g.addSideImport(importPath, ".")
+	return g
+}
+
+func (g *Generator) addSideImport(importPath, name string) {
+	if g.sideImports == nil {
+		g.sideImports = make(map[string]string)
+	}
+	g.sideImports[importPath] = name
+}
+
 // P returns a PackageRef for the given import path.
 // If the package was already registered, it returns the existing reference.
 // Otherwise, it creates a new reference with an automatically resolved alias.
@@ -60,6 +181,14 @@ func (g *Generator) P(importPath string) *PackageRef {
 		return pkg
 	}
 
+	// The generated package itself is referenced without qualifier
+	if importPath != "" && importPath == g.importPath {
+		if g.self == nil {
+			g.self = &PackageRef{importPath: importPath, gen: g}
+		}
+		return g.self
+	}
+
 	// Build set of existing aliases
 	existingAliases := make(map[string]bool)
 	for alias := range g.aliasToPath {
@@ -90,6 +219,10 @@ func (g *Generator) P(importPath string) *PackageRef {
 //	ctx := gen.PAlias("context", "ctx")
 //	ctx.Type("Context")  // => ctx.Context
 func (g *Generator) PAlias(importPath, alias string) *PackageRef {
+	if importPath != "" && importPath == g.importPath {
+		return g.P(importPath)
+	}
+
 	// Check if already registered with different alias
 	if pkg, ok := g.packages[importPath]; ok {
 		if pkg.alias != alias {
@@ -126,21 +259,59 @@ func (g *Generator) Imports() []string {
 
 // buildImportBlock creates the import block from registered packages.
 func (g *Generator) buildImportBlock() *iimport {
-	if len(g.packages) == 0 {
+	if len(g.packages) == 0 && len(g.sideImports) == 0 {
 		return nil
 	}
 
 	imp := Import()
 
+	stdLib, thirdParty := g.importGroups()
+
+	// Add standard library imports
+	for _, p := range stdLib {
+		addImport(imp, p, g.importNames(p))
+	}
+
+	// Add blank line between groups if both exist
+	if len(stdLib) > 0 && len(thirdParty) > 0 {
+		imp.AddLine()
+	}
+
+	// Add third-party imports
+	for _, p := range thirdParty {
+		addImport(imp, p, g.importNames(p))
+	}
+
+	return imp
+}
+
+// addImport adds an import spec for every name of the path.
+func addImport(imp *iimport, importPath string, names []string) {
+	for _, name := range names {
+		if name != "" {
+			imp.AddAlias(importPath, name)
+		} else {
+			imp.AddPath(importPath)
+		}
+	}
+}
+
+// importGroups returns sorted import paths grouped into standard library
+// and third-party packages.
+func (g *Generator) importGroups() (stdLib, thirdParty []string) {
 	// Sort imports for deterministic output
-	paths := make([]string, 0, len(g.packages))
+	paths := make([]string, 0, len(g.packages)+len(g.sideImports))
 	for path := range g.packages {
 		paths = append(paths, path)
 	}
+	for path := range g.sideImports {
+		if _, ok := g.packages[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
 	sort.Strings(paths)
 
 	// Group imports: standard library first, then third-party
-	var stdLib, thirdParty []string
 	for _, p := range paths {
 		if isStdLib(p) {
 			stdLib = append(stdLib, p)
@@ -148,35 +319,31 @@ func (g *Generator) buildImportBlock() *iimport {
 			thirdParty = append(thirdParty, p)
 		}
 	}
+	return stdLib, thirdParty
+}
 
-	// Add standard library imports
-	for _, p := range stdLib {
-		pkg := g.packages[p]
-		baseName := resolvePackageAlias(p, nil)
-		if pkg.alias != baseName {
-			imp.AddAlias(p, pkg.alias)
-		} else {
-			imp.AddPath(p)
+// importNames returns the names used to import the path: the explicit name
+// of a PackageRef (or empty while the alias matches the default name),
+// followed by "." or "_" for dot and blank imports.
+func (g *Generator) importNames(importPath string) []string {
+	var names []string
+	if pkg, ok := g.packages[importPath]; ok {
+		name := ""
+		if pkg.alias != resolvePackageAlias(importPath, nil) {
+			name = pkg.alias
 		}
+		names = append(names, name)
 	}
-
-	// Add blank line between groups if both exist
-	if len(stdLib) > 0 && len(thirdParty) > 0 {
-		imp.AddLine()
-	}
-
-	// Add third-party imports
-	for _, p := range thirdParty {
-		pkg := g.packages[p]
-		baseName := resolvePackageAlias(p, nil)
-		if pkg.alias != baseName {
-			imp.AddAlias(p, pkg.alias)
-		} else {
-			imp.AddPath(p)
+	switch g.sideImports[importPath] {
+	case ".":
+		names = append(names, ".")
+	case "_":
+		// A blank import is useless when the package is imported anyway.
+		if len(names) == 0 {
+			names = append(names, "_")
 		}
 	}
-
-	return imp
+	return names
 }
 
 // isStdLib checks if an import path is from the standard library.
@@ -204,24 +371,76 @@ func (g *Generator) NewGroup() (ng *Group) {
 
 // render writes the complete generated code including package declaration and imports.
 func (g *Generator) render(w io.Writer) {
+	_ = g.renderChecked(w)
+}
+
+// renderChecked renders like render, and returns the error of a loaded file
+// which could not be formatted after the code is written, see renderSource.
+func (g *Generator) renderChecked(w io.Writer) error {
+	if !g.checksum && !g.provenanceComments {
+		return g.renderFile(w, nil)
+	}
+
+	file := pool.Get()
+	defer file.Free()
+	var spans []nodeSpan
+	err := g.renderFile(file, &spans)
+	src := file.String()
+	if g.provenanceComments {
+		src = annotateProvenance(src, newSourceMap(src, spans, 0))
+	}
+	if g.checksum {
+		src = stampChecksum(src)
+	}
+	writeString(w, src)
+	return err
+}
+
+// renderFile writes the generated code without checksum. The output ranges
+// of the items of the body are recorded in spans if it is not nil.
+func (g *Generator) renderFile(w io.Writer, spans *[]nodeSpan) error {
+	if g.source != nil {
+		return g.renderSource(w, spans)
+	}
+
+	// Render body first, custom nodes could register imports while rendering
+	body := pool.Get()
+	defer body.Free()
+	g.g.render(&RenderContext{w: body, gen: g, spans: spans})
+
+	head := pool.Get()
+	defer head.Free()
+
+	// Write build constraint, it must be followed by an empty line
+	if g.buildConstraint != "" {
+		writeStringF(head, "//go:build %s\n\n", g.buildConstraint)
+	}
+
 	// Write header comment (before package declaration)
 	if g.headerComment != "" {
-		writeStringF(w, "// %s\n", g.headerComment)
+		writeStringF(head, "%s\n", formatHeaderComment(g.headerComment))
 	}
 
 	// Write package declaration
 	if g.packageName != "" {
-		writeStringF(w, "package %s\n\n", g.packageName)
+		writeStringF(head, "package %s\n\n", g.packageName)
 	}
 
 	// Write import block
 	if imp := g.buildImportBlock(); imp != nil {
-		imp.render(w)
-		writeString(w, "\n\n")
+		imp.render(head)
+		writeString(head, "\n\n")
 	}
 
-	// Write body
-	g.g.render(w)
+	if spans != nil {
+		shift := len(head.Bytes())
+		for i := range *spans {
+			(*spans)[i].start += shift
+			(*spans)[i].end += shift
+		}
+	}
+	writeString(w, head.String(), body.String())
+	return nil
 }
 
 // Write will write the complete generated code into the given writer.
@@ -231,25 +450,23 @@ func (g *Generator) Write(w io.Writer) {
 }
 
 // WriteFile will write the complete generated code into the given path.
+// The file is written atomically and only if its content changes, see
+// WriteFileWith.
 func (g *Generator) WriteFile(path string) error {
-	file, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("create file %s: %s", path, err)
-	}
-	defer file.Close()
-	g.render(file)
-	return nil
+	_, err := g.WriteFileWith(path, WriteOptions{})
+	return err
 }
 
 // AppendFile will append the group after the given path.
 // Note: This only appends the body, not package/imports.
+// Use PatchFile to merge the imports and replace existing declarations.
 func (g *Generator) AppendFile(path string) error {
 	file, err := os.OpenFile(path, os.O_APPEND|os.O_RDWR, 0644)
 	if err != nil {
 		return fmt.Errorf("create file %s: %s", path, err)
 	}
 	defer file.Close()
-	g.g.render(file)
+	g.g.render(&RenderContext{w: file, gen: g})
 	return nil
 }
 
@@ -272,158 +489,48 @@ func (g *Generator) Bytes() []byte {
 	buf := pool.Get()
 	defer buf.Free()
 	g.render(buf)
-	return buf.Bytes()
-}
-
-// Merge merges another Generator's body and imports into this one.
-// This is useful when multiple generators need to output to the same file.
-// The other generator's body is appended to this generator's body.
-// Import paths are merged, with alias conflicts resolved automatically.
-// All PackageRef in the merged generator will be updated to use the correct aliases.
-func (g *Generator) Merge(other *Generator) *Generator {
-	if other == nil {
-		return g
-	}
-
-	// Build alias mapping: old alias -> new alias
-	aliasMapping := make(map[string]string)
-
-	// Merge imports and build mapping
-	for importPath, pkg := range other.packages {
-		oldAlias := pkg.alias
-
-		if _, exists := g.packages[importPath]; !exists {
-			// Package not yet registered
-			// Check if the alias is already used by another package
-			if existingPath, aliasUsed := g.aliasToPath[oldAlias]; aliasUsed && existingPath != importPath {
-				// Alias conflict, let P() auto-resolve
-				newPkg := g.P(importPath)
-				aliasMapping[oldAlias] = newPkg.alias
-			} else {
-				// No conflict, preserve the original alias
-				newPkg := g.PAlias(importPath, oldAlias)
-				aliasMapping[oldAlias] = newPkg.alias
-			}
-		} else {
-			// Package already exists, use existing alias
-			aliasMapping[oldAlias] = g.packages[importPath].alias
-		}
-	}
-
-	// Update all PackageRef in other's body to point to this generator
-	// and use the new aliases
-	updatePackageRefs(other.g, g, aliasMapping)
-
-	// Merge body - append other's body content to this generator
-	g.g.Append(other.g)
-
-	return g
+	// The buffer is reused by other renders once it's freed.
+	return bytes.Clone(buf.Bytes())
 }
 
-// updatePackageRefs recursively updates all PackageRef in a node tree
-// to use the new generator and alias mapping
+// updatePackageRefs updates all PackageRef in a node tree to use the new
+// generator and alias mapping
 func updatePackageRefs(node Node, newGen *Generator, aliasMapping map[string]string) {
-	if node == nil {
-		return
-	}
-
-	switch n := node.(type) {
-	case *Group:
-		for _, item := range n.items {
-			updatePackageRefs(item, newGen, aliasMapping)
+	Inspect(node, func(node Node) bool {
+		n, ok := node.(*qualifiedIdent)
+		if !ok || n.pkg == nil {
+			return true
 		}
-	case *qualifiedIdent:
 		// Update the PackageRef's generator and alias
-		if n.pkg != nil {
-			oldAlias := n.pkg.alias
-			if newAlias, ok := aliasMapping[oldAlias]; ok {
-				// Update to use the new generator's PackageRef
-				if newPkg, exists := newGen.packages[n.pkg.importPath]; exists {
-					n.pkg = newPkg
-				} else {
-					// If not found, at least update the alias
-					n.pkg.gen = newGen
-					n.pkg.alias = newAlias
-				}
+		oldAlias := n.pkg.alias
+		if newAlias, ok := aliasMapping[oldAlias]; ok {
+			// Update to use the new generator's PackageRef
+			if newPkg, exists := newGen.packages[n.pkg.importPath]; exists {
+				n.pkg = newPkg
+			} else {
+				// If not found, at least update the alias
+				n.pkg = &PackageRef{importPath: n.pkg.importPath, alias: newAlias, gen: newGen}
 			}
 		}
-	case *sliceType:
-		updatePackageRefs(n.elem, newGen, aliasMapping)
-	case *ptrType:
-		updatePackageRefs(n.elem, newGen, aliasMapping)
-	case *mapType:
-		updatePackageRefs(n.key, newGen, aliasMapping)
-		updatePackageRefs(n.value, newGen, aliasMapping)
-	case *chanType:
-		updatePackageRefs(n.elem, newGen, aliasMapping)
-	case *genericType:
-		updatePackageRefs(n.base, newGen, aliasMapping)
-		for _, arg := range n.args {
-			updatePackageRefs(arg, newGen, aliasMapping)
-		}
-	case *ifunction:
-		updatePackageRefs(n.receiver, newGen, aliasMapping)
-		updatePackageRefs(n.parameters, newGen, aliasMapping)
-		updatePackageRefs(n.results, newGen, aliasMapping)
-		updatePackageRefs(n.body, newGen, aliasMapping)
-		if n.call != nil {
-			updatePackageRefs(n.call, newGen, aliasMapping)
-		}
-	case *istruct:
-		updatePackageRefs(n.items, newGen, aliasMapping)
-	case *iinterface:
-		updatePackageRefs(n.items, newGen, aliasMapping)
-	case *isignature:
-		updatePackageRefs(n.parameters, newGen, aliasMapping)
-		updatePackageRefs(n.results, newGen, aliasMapping)
-		updatePackageRefs(n.comments, newGen, aliasMapping)
-	case *ivar:
-		updatePackageRefs(n.items, newGen, aliasMapping)
-	case *iconst:
-		updatePackageRefs(n.items, newGen, aliasMapping)
-	case *iif:
-		updatePackageRefs(n.judge, newGen, aliasMapping)
-		updatePackageRefs(n.body, newGen, aliasMapping)
-	case *ifor:
-		updatePackageRefs(n.judge, newGen, aliasMapping)
-		updatePackageRefs(n.body, newGen, aliasMapping)
-	case *iswitch:
-		updatePackageRefs(n.judge, newGen, aliasMapping)
-		for _, c := range n.cases {
-			updatePackageRefs(c.judge, newGen, aliasMapping)
-			updatePackageRefs(c.body, newGen, aliasMapping)
-		}
-		if n.defaultCase != nil {
-			updatePackageRefs(n.defaultCase.judge, newGen, aliasMapping)
-			updatePackageRefs(n.defaultCase.body, newGen, aliasMapping)
-		}
-	case *ireturn:
-		updatePackageRefs(n.items, newGen, aliasMapping)
-	case *icall:
-		updatePackageRefs(n.owner, newGen, aliasMapping)
-		updatePackageRefs(n.items, newGen, aliasMapping)
-		updatePackageRefs(n.calls, newGen, aliasMapping)
-	case *ivalue:
-		updatePackageRefs(n.items, newGen, aliasMapping)
-	case *islice:
-		updatePackageRefs(n.elemType, newGen, aliasMapping)
-		updatePackageRefs(n.items, newGen, aliasMapping)
-	case *iarray:
-		updatePackageRefs(n.elemType, newGen, aliasMapping)
-		updatePackageRefs(n.items, newGen, aliasMapping)
-	case *itype:
-		updatePackageRefs(n.item, newGen, aliasMapping)
-	case *idefer:
-		updatePackageRefs(n.body, newGen, aliasMapping)
-	case *ifield:
-		updatePackageRefs(n.name, newGen, aliasMapping)
-		updatePackageRefs(n.typ, newGen, aliasMapping)
-		updatePackageRefs(n.value, newGen, aliasMapping)
-		// For other types like *istring, *lit, etc., no action needed
-	}
+		return true
+	})
 }
 
 // PackageName returns the current package name.
 func (g *Generator) PackageName() string {
 	return g.packageName
 }
+
+// declaredPackage returns the package name set by SetPackage, or the name of
+// the first package clause in the body.
+func (g *Generator) declaredPackage() string {
+	if g.packageName != "" {
+		return g.packageName
+	}
+	for _, item := range g.g.items {
+		if p, ok := item.(*ipackage); ok {
+			return p.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package gg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerator_SideImports(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.SetBuildConstraint("//go:build ignore")
+	gen.ImportBlank("embed")
+	gen.ImportBlank("github.com/lib/pq")
+	gen.ImportDot("github.com/onsi/gomega")
+	// A blank import is dropped once the package is referenced.
+	gen.ImportBlank("fmt")
+	gen.Body().NewFunction("main").AddBody(gen.P("fmt").Call("Println", Lit("hello")))
+
+	expected := `//go:build ignore
+
+package main
+
+import (
+	_ "embed"
+	"fmt"
+
+	_ "github.com/lib/pq"
+	. "github.com/onsi/gomega"
+)
+
+func main() {
+	fmt.Println("hello")
+}
+`
+	output := gen.String()
+	compareAST(t, expected, output)
+	if !strings.HasPrefix(output, "//go:build ignore\n\npackage main") {
+		t.Errorf("Expected build constraint before package, got:\n%s", output)
+	}
+}
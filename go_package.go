@@ -0,0 +1,220 @@
+package gg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// GoPackage holds the files of a generated package. Every file is a
+// Generator which shares the package name and the import path, so
+// references to the package itself are not qualified in any file.
+//
+// Example:
+//
+//	pkg := gg.NewPackage("models", "github.com/example/models")
+//	pkg.File("types.go").Body().NewStruct("User")
+//	pkg.File("user_string.go").Body().NewFunction("String").WithReceiver("u", "User")
+//	err := pkg.WriteDir("./models")
+type GoPackage struct {
+	name       string
+	importPath string
+	goVersion  string
+
+	files map[string]*Generator
+	names []string // file names in creation order
+}
+
+// NewPackage creates a package with the name and the import path, the
+// import path could be empty if the package is never referenced by itself.
+func NewPackage(name, importPath string) *GoPackage {
+	return &GoPackage{
+		name:       name,
+		importPath: importPath,
+		files:      make(map[string]*Generator),
+	}
+}
+
+// Name returns the package name.
+func (p *GoPackage) Name() string {
+	return p.name
+}
+
+// ImportPath returns the import path of the package.
+func (p *GoPackage) ImportPath() string {
+	return p.importPath
+}
+
+// SetGoVersion sets the Go version targeted by all files of the package,
+// see Generator.SetGoVersion.
+func (p *GoPackage) SetGoVersion(version string) *GoPackage {
+	p.goVersion = normalizeGoVersion(version)
+	for _, name := range p.names {
+		p.files[name].goVersion = p.goVersion
+	}
+	return p
+}
+
+// Ref returns a PackageRef of this package in another generated file, like
+// a file of another package of the same Module. It panics if the package
+// has no import path.
+func (p *GoPackage) Ref(gen *Generator) *PackageRef {
+	if p.importPath == "" {
+		panic(fmt.Sprintf("package %s has no import path", p.name))
+	}
+	return gen.P(p.importPath)
+}
+
+// File returns the generator of the file, like `types.go`, which is created
+// on the first call. Test files use the package name too, call SetPackage
+// with the `_test` suffix for an external test package, which refers to the
+// package with its name and imports it.
+func (p *GoPackage) File(name string) *Generator {
+	if gen, ok := p.files[name]; ok {
+		return gen
+	}
+	gen := New().SetPackage(p.name).SetGoVersion(p.goVersion)
+	if p.importPath != "" {
+		gen.SetImportPath(p.importPath)
+	}
+	p.files[name] = gen
+	p.names = append(p.names, name)
+	return gen
+}
+
+// AddFile adds an existing generator as a file of the package. The package
+// name and the import path are set if the generator doesn't have them, an
+// error is returned if they are different or the file already exists.
+func (p *GoPackage) AddFile(name string, gen *Generator) error {
+	if _, ok := p.files[name]; ok {
+		return fmt.Errorf("package %s: file %s already exists", p.name, name)
+	}
+	if err := p.checkFile(name, gen); err != nil {
+		return err
+	}
+	if gen.declaredPackage() == "" {
+		gen.SetPackage(p.name)
+	}
+	// An external test package imports the package under test.
+	if gen.importPath == "" && p.importPath != "" && !strings.HasSuffix(gen.declaredPackage(), "_test") {
+		gen.SetImportPath(p.importPath)
+	}
+	if gen.goVersion == "" {
+		gen.SetGoVersion(p.goVersion)
+	}
+	p.files[name] = gen
+	p.names = append(p.names, name)
+	return nil
+}
+
+// Files returns the file names in creation order.
+func (p *GoPackage) Files() []string {
+	return slices.Clone(p.names)
+}
+
+// Check reports files which are not in the package, and declarations which
+// are declared by more than one file. The error is a *PackageConflictError
+// for duplicated declarations.
+func (p *GoPackage) Check() error {
+	for _, name := range p.names {
+		if err := p.checkFile(name, p.files[name]); err != nil {
+			return err
+		}
+	}
+
+	// Files of an external test package have their own scope.
+	type scoped struct{ pkg, name string }
+	declared := make(map[scoped][]string)
+	var order []scoped
+	for _, name := range p.names {
+		gen := p.files[name]
+		eachDecl(gen.g, func(_ *Group, _ int, n Node) bool {
+			for _, decl := range declNames(n) {
+				key := scoped{pkg: gen.declaredPackage(), name: decl}
+				if _, ok := declared[key]; !ok {
+					order = append(order, key)
+				}
+				declared[key] = append(declared[key], name)
+			}
+			return true
+		})
+	}
+
+	var conflicts []PackageConflict
+	for _, key := range order {
+		if files := declared[key]; len(files) > 1 {
+			conflicts = append(conflicts, PackageConflict{Name: key.name, Files: files})
+		}
+	}
+	if len(conflicts) > 0 {
+		return &PackageConflictError{Package: p.name, Conflicts: conflicts}
+	}
+	return nil
+}
+
+// checkFile reports an error if the file belongs to another package.
+func (p *GoPackage) checkFile(name string, gen *Generator) error {
+	pkg := gen.declaredPackage()
+	if pkg != "" && pkg != p.name && !(strings.HasSuffix(name, "_test.go") && pkg == p.name+"_test") {
+		return fmt.Errorf("package %s: file %s is in package %s", p.name, name, pkg)
+	}
+	if gen.importPath != "" && p.importPath != "" && gen.importPath != p.importPath {
+		return fmt.Errorf("package %s: file %s has import path %q, want %q", p.name, name, gen.importPath, p.importPath)
+	}
+	return nil
+}
+
+// WriteDir checks the package and writes all files into the directory, which
+// is created if it doesn't exist.
+func (p *GoPackage) WriteDir(dir string) error {
+	_, err := p.WriteDirWith(dir, WriteOptions{})
+	return err
+}
+
+// WriteDirWith writes all files like WriteDir, every file is written with
+// Generator.WriteFileWith. It returns the results of the written files.
+func (p *GoPackage) WriteDirWith(dir string, opts WriteOptions) ([]WriteResult, error) {
+	if err := p.Check(); err != nil {
+		return nil, err
+	}
+	if !opts.DryRun {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create dir %s: %s", dir, err)
+		}
+	}
+	results := make([]WriteResult, 0, len(p.names))
+	for _, name := range p.names {
+		res, err := p.files[name].WriteFileWith(filepath.Join(dir, name), opts)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// PackageConflict describes a declaration which is declared by several
+// files of a package.
+type PackageConflict struct {
+	// Name is the declared name, like `User` or `User.String` for methods.
+	Name string
+	// Files are the files declaring the name, in creation order.
+	Files []string
+}
+
+// PackageConflictError is returned by GoPackage.Check when declarations
+// conflict.
+type PackageConflictError struct {
+	Package   string
+	Conflicts []PackageConflict
+}
+
+func (e *PackageConflictError) Error() string {
+	items := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		items = append(items, fmt.Sprintf("%s in %s", c.Name, strings.Join(c.Files, ", ")))
+	}
+	return fmt.Sprintf("package %s: redeclared %s", e.Package, strings.Join(items, "; "))
+}
@@ -0,0 +1,140 @@
+package gg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoPackage_WriteDir(t *testing.T) {
+	pkg := NewPackage("models", "github.com/example/models")
+
+	types := pkg.File("types.go")
+	types.Body().NewStruct("User").AddField("Name", "string")
+
+	methods := pkg.File("user_string.go")
+	methods.Body().NewFunction("String").
+		WithReceiver("u", methods.P("github.com/example/models").Type("User")).
+		AddResult("", "string").
+		AddBody(Return("u.Name"))
+
+	test := pkg.File("user_test.go")
+	test.SetPackage("models_test")
+	test.Body().NewFunction("TestUser").
+		AddParameter("t", test.P("testing").Type("*T")).
+		AddBody(String("_ = models.User{}"))
+	test.P("github.com/example/models")
+
+	if pkg.File("types.go") != types {
+		t.Errorf("Expected the same generator for the same file")
+	}
+	if got := strings.Join(pkg.Files(), ","); got != "types.go,user_string.go,user_test.go" {
+		t.Errorf("Unexpected files: %s", got)
+	}
+
+	dir := filepath.Join(t.TempDir(), "models")
+	if err := pkg.WriteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "user_string.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareAST(t, `package models
+
+func (u User) String() (string) {
+	return u.Name
+}
+`, string(data))
+
+	data, err = os.ReadFile(filepath.Join(dir, "user_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "package models_test") {
+		t.Errorf("Expected external test package, got:\n%s", data)
+	}
+}
+
+func TestGoPackage_Check(t *testing.T) {
+	pkg := NewPackage("models", "")
+	pkg.File("a.go").Body().NewStruct("User")
+	pkg.File("a.go").Body().NewFunction("init")
+	pkg.File("b.go").Body().NewFunction("init")
+	pkg.File("b.go").Body().NewFunction("Validate").WithReceiver("u", "*User")
+	pkg.File("c.go").Body().AddType("User", "string")
+	pkg.File("c.go").Body().NewFunction("Validate").WithReceiver("u", "User")
+	// External test packages have their own scope.
+	pkg.File("a_test.go").SetPackage("models_test").Body().NewStruct("User")
+
+	err := pkg.Check()
+	var conflictErr *PackageConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected *PackageConflictError, got %v", err)
+	}
+	expected := "package models: redeclared User in a.go, c.go; User.Validate in b.go, c.go"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+	if err := pkg.WriteDir(t.TempDir()); err == nil {
+		t.Errorf("Expected WriteDir to fail")
+	}
+}
+
+func TestGoPackage_AddFile(t *testing.T) {
+	pkg := NewPackage("models", "github.com/example/models")
+
+	gen := New()
+	gen.P("github.com/example/models")
+	if err := pkg.AddFile("types.go", gen); err != nil {
+		t.Fatal(err)
+	}
+	if gen.PackageName() != "models" || gen.ImportPath() != "github.com/example/models" {
+		t.Errorf("Expected package to be set, got %s %s", gen.PackageName(), gen.ImportPath())
+	}
+	if len(gen.Imports()) != 0 {
+		t.Errorf("Expected references to the package itself not to be imported")
+	}
+
+	if err := pkg.AddFile("types.go", New()); err == nil {
+		t.Errorf("Expected error for duplicated file")
+	}
+	if err := pkg.AddFile("other.go", New().SetPackage("other")); err == nil {
+		t.Errorf("Expected error for another package")
+	}
+	if err := pkg.AddFile("x.go", New().SetImportPath("github.com/example/x")); err == nil {
+		t.Errorf("Expected error for another import path")
+	}
+
+	pkg.File("b.go").SetPackage("other")
+	if err := pkg.Check(); err == nil || err.Error() != "package models: file b.go is in package other" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestGoPackage_ExternalTest(t *testing.T) {
+	pkg := NewPackage("models", "github.com/example/models")
+	gen := pkg.File("user_test.go")
+	before := pkg.Ref(gen)
+	gen.SetPackage("models_test")
+	gen.Body().NewVar().AddField("_", before.Type("User"))
+	gen.Body().NewFunction("TestUser").AddBody(pkg.Ref(gen).Call("NewUser"))
+
+	output := gen.String()
+	for _, s := range []string{`"github.com/example/models"`, "models.User", "models.NewUser()"} {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected %s, got:\n%s", s, output)
+		}
+	}
+
+	other := New().SetPackage("models_test")
+	if err := pkg.AddFile("other_test.go", other); err != nil {
+		t.Fatal(err)
+	}
+	if other.ImportPath() != "" {
+		t.Errorf("Expected no import path for an external test package, got %s", other.ImportPath())
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"unicode/utf8"
 )
 
 func NewGroup() *Group {
@@ -32,6 +34,18 @@ func newGroup(open, close, sep string) *Group {
 	}
 }
 
+// tabWidth is the width of an indentation tab while measuring lines.
+const tabWidth = 4
+
+// newListGroup creates a group for a list like call arguments or
+// parameters, which is wrapped one item per line when it's longer than the
+// line width, see Generator.SetLineWidth.
+func newListGroup(open, close, sep string) *Group {
+	g := newGroup(open, close, sep)
+	g.wrap = true
+	return g
+}
+
 type Group struct {
 	items     []Node
 	open      string
@@ -39,11 +53,20 @@ type Group struct {
 	separator string
 
 	// NewIf this result is true, we will omit the wrap like `()`, `{}`.
-	omitWrapIf func() bool
+	// It receives the group itself, so a copied group keeps working.
+	omitWrapIf func(g *Group) bool
 
 	// mergeFields when true, consecutive fields with the same type will be merged.
 	// Example: (a string, b string, c int) => (a, b string, c int)
 	mergeFields bool
+
+	// multiLine renders one item per line with trailing separators, and
+	// wrap does it while the group doesn't fit in the line width.
+	multiLine bool
+	wrap      bool
+
+	// sites are the call sites which appended the items, see SetProvenance.
+	sites map[Node]Provenance
 }
 
 func (g *Group) length() int {
@@ -54,14 +77,16 @@ func (g *Group) shouldOmitWrap() bool {
 	if g.omitWrapIf == nil {
 		return false
 	}
-	return g.omitWrapIf()
+	return g.omitWrapIf(g)
 }
 
 func (g *Group) append(node ...interface{}) *Group {
 	if len(node) == 0 {
 		return g
 	}
-	g.items = append(g.items, parseNodes(node)...)
+	nodes := parseNodes(node)
+	g.recordProvenance(nodes)
+	g.items = append(g.items, nodes...)
 	return g
 }
 
@@ -71,19 +96,36 @@ func (g *Group) Append(node ...any) *Group {
 }
 
 func (g *Group) render(w io.Writer) {
+	g.renderWrapped(w, g.wrap && exceedsLineWidth(w, g.renderLine))
+}
+
+// renderWrapped renders one item per line if wrap or multiLine is set.
+func (g *Group) renderWrapped(w io.Writer, wrap bool) {
+	if g.open != "" && g.length() > 0 && !g.shouldOmitWrap() && (g.multiLine || wrap) {
+		g.renderMultiLine(w)
+		return
+	}
+	g.renderLine(w)
+}
+
+// renderLine renders the items separated by the separator.
+func (g *Group) renderLine(w io.Writer) {
 	if g.open != "" && !g.shouldOmitWrap() {
 		writeString(w, g.open)
+		if strings.HasSuffix(g.open, "{\n") {
+			defer enterBlock(w)()
+		}
 	}
 
 	if g.mergeFields {
-		g.renderMergedFields(w)
+		g.renderMergedFields(w, measuredSeparator(w, g.separator))
 	} else {
 		isfirst := true
 		for _, node := range g.items {
 			if !isfirst {
-				writeString(w, g.separator)
+				writeString(w, measuredSeparator(w, g.separator))
 			}
-			node.render(w)
+			renderItem(w, g, node)
 			isfirst = false
 		}
 	}
@@ -93,9 +135,69 @@ func (g *Group) render(w io.Writer) {
 	}
 }
 
-// renderMergedFields renders fields with consecutive same types merged.
+// renderMultiLine renders one item per line, each item is followed by the
+// separator, like:
+//
+//	(
+//		a int,
+//		b string,
+//	)
+func (g *Group) renderMultiLine(w io.Writer) {
+	sep := strings.TrimSpace(g.separator) + "\n"
+	writeString(w, g.open, "\n")
+	if g.mergeFields {
+		g.renderMergedFields(w, sep)
+		writeString(w, sep)
+	} else {
+		for _, node := range g.items {
+			renderItem(w, g, node)
+			writeString(w, sep)
+		}
+	}
+	writeString(w, g.close)
+}
+
+// exceedsLineWidth reports whether the output of render would be longer
+// than the line width of the generator. Tabs of the indentation are counted
+// as tabWidth columns.
+func exceedsLineWidth(w io.Writer, render func(w io.Writer)) bool {
+	ctx, ok := w.(*RenderContext)
+	if !ok || ctx.measuring || ctx.gen == nil || ctx.gen.lineWidth <= 0 {
+		return false
+	}
+	buf := pool.Get()
+	defer buf.Free()
+	// Nested groups are measured in one line too.
+	render(&RenderContext{w: buf, gen: ctx.gen, indent: ctx.indent, col: ctx.col, measuring: true})
+	line, _, _ := strings.Cut(buf.String(), "\n")
+	return ctx.indent*tabWidth+ctx.col+utf8.RuneCountInString(line) > ctx.gen.lineWidth
+}
+
+// renderSignature renders the parameters and results of a function, the
+// parameters are wrapped first if the signature doesn't fit in the line
+// width, like gofmt'd code.
+func renderSignature(w io.Writer, params, results *Group) {
+	wrap := params.wrap && exceedsLineWidth(w, func(w io.Writer) {
+		params.renderLine(w)
+		results.render(w)
+	})
+	params.renderWrapped(w, wrap)
+	results.render(w)
+}
+
+// measuredSeparator returns the separator as it is written by gofmt while
+// measuring the line width, the output has no space after commas.
+func measuredSeparator(w io.Writer, sep string) string {
+	if ctx, ok := w.(*RenderContext); ok && ctx.measuring && sep == "," {
+		return ", "
+	}
+	return sep
+}
+
+// renderMergedFields renders fields with consecutive same types merged, sep
+// is written between them.
 // Example: (a string, b string, c int) => (a, b string, c int)
-func (g *Group) renderMergedFields(w io.Writer) {
+func (g *Group) renderMergedFields(w io.Writer, sep string) {
 	type fieldInfo struct {
 		name string
 		typ  string
@@ -106,23 +208,13 @@ func (g *Group) renderMergedFields(w io.Writer) {
 	for _, node := range g.items {
 		if f, ok := node.(*ifield); ok {
 			// Get name and type as strings
-			nameBuf := pool.Get()
-			f.name.render(nameBuf)
-			name := nameBuf.String()
-			nameBuf.Free()
-
-			typBuf := pool.Get()
-			f.value.render(typBuf)
-			typ := typBuf.String()
-			typBuf.Free()
+			name := renderNested(w, f.name)
+			typ := renderNested(w, f.value)
 
 			fields = append(fields, fieldInfo{name: name, typ: typ})
 		} else if mf, ok := node.(*multiNameField); ok {
 			// multiNameField already handles multiple names with same type
-			typBuf := pool.Get()
-			mf.typ.render(typBuf)
-			typ := typBuf.String()
-			typBuf.Free()
+			typ := renderNested(w, mf.typ)
 
 			for _, name := range mf.names {
 				fields = append(fields, fieldInfo{name: name, typ: typ})
@@ -148,7 +240,7 @@ func (g *Group) renderMergedFields(w io.Writer) {
 		isfirst := true
 		for _, f := range fields {
 			if !isfirst {
-				writeString(w, g.separator)
+				writeString(w, sep)
 			}
 			writeString(w, f.typ)
 			isfirst = false
@@ -161,7 +253,7 @@ func (g *Group) renderMergedFields(w io.Writer) {
 	i := 0
 	for i < len(fields) {
 		if !isfirst {
-			writeString(w, g.separator)
+			writeString(w, sep)
 		}
 
 		// Find all consecutive fields with the same type
@@ -268,6 +360,12 @@ func (g *Group) NewFor(judge interface{}) *ifor {
 	return i
 }
 
+func (g *Group) NewForN(name string, n interface{}) *ifor {
+	i := ForN(name, n)
+	g.append(i)
+	return i
+}
+
 func (g *Group) NewSwitch(judge interface{}) *iswitch {
 	i := Switch(judge)
 	g.append(i)
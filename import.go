@@ -11,8 +11,8 @@ func Import() *iimport {
 	i := &iimport{
 		items: newGroup("(", ")", "\n"),
 	}
-	i.items.omitWrapIf = func() bool {
-		return i.items.length() <= 1
+	i.items.omitWrapIf = func(g *Group) bool {
+		return g.length() <= 1
 	}
 	return i
 }
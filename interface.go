@@ -13,16 +13,16 @@ func signature(name string) *isignature {
 	i := &isignature{
 		name:       name,
 		comments:   newGroup("", "", "\n"),
-		parameters: newGroup("(", ")", ","),
-		results:    newGroup("(", ")", ","),
+		parameters: newListGroup("(", ")", ","),
+		results:    newListGroup("(", ")", ","),
 	}
 	// Enable field merging for parameters and results
 	i.parameters.mergeFields = true
 	i.results.mergeFields = true
 	// We should omit the `()` if result is empty
 	// Read about omit in NewFunction comments.
-	i.results.omitWrapIf = func() bool {
-		l := i.results.length()
+	i.results.omitWrapIf = func(g *Group) bool {
+		l := g.length()
 		if l == 0 {
 			// There is no result fields, we can omit `()` safely.
 			return true
@@ -36,10 +36,8 @@ func (i *isignature) render(w io.Writer) {
 	// Render function name
 	writeString(w, i.name)
 
-	// Render parameters
-	i.parameters.render(w)
-	// Render results
-	i.results.render(w)
+	// Render parameters and results
+	renderSignature(w, i.parameters, i.results)
 }
 
 func (i *isignature) AddParameter(name, typ interface{}) *isignature {
@@ -53,22 +51,38 @@ func (i *isignature) AddResult(name, typ interface{}) *isignature {
 }
 
 type iinterface struct {
-	name  string
-	items *Group
+	name       string
+	typeParams *Group
+	items      *Group
 }
 
 func Interface(name string) *iinterface {
 	return &iinterface{
-		name:  name,
-		items: newGroup("{\n", "}", "\n"),
+		name:       name,
+		typeParams: newTypeParams(),
+		items:      newGroup("{\n", "}", "\n"),
 	}
 }
 
 func (i *iinterface) render(w io.Writer) {
-	writeStringF(w, "type %s interface", i.name)
+	writeStringF(w, "type %s", i.name)
+	i.typeParams.render(w)
+	writeString(w, " interface")
 	i.items.render(w)
 }
 
+// AddTypeParameter adds a type parameter, like `type Getter[T any] interface`.
+func (i *iinterface) AddTypeParameter(name, constraint interface{}) *iinterface {
+	i.typeParams.append(field(name, constraint, " "))
+	return i
+}
+
+// AddEmbedded will embed another interface or a type constraint, like `io.Reader`.
+func (i *iinterface) AddEmbedded(typ interface{}) *iinterface {
+	i.items.append(typ)
+	return i
+}
+
 func (i *iinterface) NewFunction(name string) *isignature {
 	sig := signature(name)
 	i.items.append(sig)
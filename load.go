@@ -0,0 +1,366 @@
+package gg
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// sourceFile is the Go file a generator was loaded from.
+type sourceFile struct {
+	src   []byte
+	fset  *token.FileSet
+	file  *ast.File
+	decls []sourceDecl
+}
+
+// sourceDecl is a declaration of the loaded file, src[start:end] is its text
+// with the doc comment, and rendered is the output of its nodes when loaded.
+type sourceDecl struct {
+	node       Node
+	start, end int
+	rendered   string
+}
+
+// LoadFile parses an existing Go file into a generator, see Load.
+func LoadFile(path string) (*Generator, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file %s: %s", path, err)
+	}
+	return Load(path, src)
+}
+
+// Load parses Go source into a generator, so it could be edited and written
+// back. The imports of the file are registered as package references, and
+// its declarations are converted into nodes of the body, which could be
+// found and changed with FindFunction, FindMethod, FindType and so on.
+//
+// When the generator is rendered, the declarations which have not been
+// changed, the comments and blank lines around them and the header of the
+// file are copied byte for byte. Changed and new declarations are rendered
+// and formatted with gofmt, and missing imports are added into the import
+// block of the file. SetPackage renames the package clause, the header
+// comment and build constraint of the generator are not used.
+//
+// Example:
+//
+//	gen, err := gg.LoadFile("user.go")
+//	gen.Body().FindMethod("User", "Validate").
+//		AddBody(gg.Return("nil"))
+//	err = gen.WriteFile("user.go")
+func Load(filename string, src []byte) (*Generator, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("load file %s: %s", filename, err)
+	}
+
+	gen := New().SetPackage(file.Name.Name)
+	im := NewASTImporter(gen, fset, file)
+	source := &sourceFile{src: src, fset: fset, file: file}
+	for _, d := range file.Decls {
+		n, err := im.Decl(d)
+		if err != nil {
+			return nil, fmt.Errorf("load file %s: %s", filename, err)
+		}
+		if n == nil {
+			continue
+		}
+		start := d.Pos()
+		if doc := declDoc(d); doc != nil {
+			start = doc.Pos()
+		}
+		unit := NewGroup()
+		unit.append(im.docComment(declDoc(d))...)
+		unit.append(n)
+
+		if len(source.decls) > 0 {
+			gen.g.AddLine()
+		}
+		gen.g.items = append(gen.g.items, unit.items...)
+		source.decls = append(source.decls, sourceDecl{
+			node:     n,
+			start:    fset.Position(start).Offset,
+			end:      fset.Position(d.End()).Offset,
+			rendered: gen.renderNode(unit),
+		})
+	}
+	gen.source = source
+	return gen, nil
+}
+
+// clone returns a copy of the source for a copy of the body, the nodes of
+// the declarations are looked up by their index.
+func (s *sourceFile) clone(from, to *Group) *sourceFile {
+	c := *s
+	c.decls = make([]sourceDecl, len(s.decls))
+	for k, d := range s.decls {
+		c.decls[k] = d
+		c.decls[k].node = nil
+		for idx, item := range from.items {
+			if item == d.node {
+				c.decls[k].node = to.items[idx]
+				break
+			}
+		}
+	}
+	return &c
+}
+
+// renderSource writes the loaded file with the changes of the generator.
+// The output ranges of the items of the body are recorded in spans if it is
+// not nil, an unchanged declaration is one range. Imports which were used
+// by the file but are not used after the changes are removed. It returns an
+// error if the changed code could not be formatted, which is written as it
+// is rendered.
+func (g *Generator) renderSource(w io.Writer, spans *[]nodeSpan) error {
+	s := g.source
+	origin := make(map[Node]int, len(s.decls))
+	for k, d := range s.decls {
+		if d.node != nil {
+			origin[d.node] = k
+		}
+	}
+
+	// The body is split into segments, a declaration of the file with its
+	// comments, or the new code between them. decl is -1 for new code, and
+	// spans are relative to the text.
+	type segment struct {
+		decl  int
+		text  string
+		spans []nodeSpan
+	}
+	var segments []segment
+	var loose []Node
+	var formatErr error
+	flush := func(nodes []Node) {
+		unit := NewGroup()
+		unit.items, unit.sites = nodes, g.g.sites
+		text, spans, err := g.renderDecls(unit)
+		if err != nil && formatErr == nil {
+			formatErr = err
+		}
+		if strings.TrimSpace(text) != "" {
+			segments = append(segments, segment{decl: -1, text: text, spans: spans})
+		}
+	}
+	for idx, n := range g.g.items {
+		k, ok := origin[n]
+		if !ok {
+			loose = append(loose, n)
+			continue
+		}
+		lead := leadingComments(g.g, idx)
+		flush(loose[:len(loose)-(idx-lead)])
+		loose = nil
+
+		unit := NewGroup()
+		unit.items, unit.sites = g.g.items[lead:idx+1], g.g.sites
+		seg := segment{decl: k, text: s.decls[k].text(s.src)}
+		if g.renderNode(unit) != s.decls[k].rendered {
+			var err error
+			seg.text, seg.spans, err = g.renderDecls(unit)
+			if err != nil && formatErr == nil {
+				formatErr = err
+			}
+		} else {
+			seg.spans = []nodeSpan{{node: n, group: g.g, start: 0, end: len(seg.text)}}
+		}
+		segments = append(segments, seg)
+	}
+	flush(loose)
+
+	// The header of the file, with missing imports.
+	end := len(s.src)
+	if len(s.decls) > 0 {
+		end = s.decls[0].start
+	}
+	var edits []textEdit
+	if edit, ok := g.importEdit(s.file, s.src, s.offset, fileImports(s.file)); ok {
+		edits = append(edits, edit)
+	}
+	if g.packageName != "" && g.packageName != s.file.Name.Name {
+		edits = append(edits, textEdit{
+			start: s.offset(s.file.Name.Pos()),
+			end:   s.offset(s.file.Name.End()),
+			text:  g.packageName,
+		})
+	}
+	header := applyEdits(string(s.src[:end]), edits)
+
+	var out strings.Builder
+	recorded := 0
+	if spans != nil {
+		recorded = len(*spans)
+	}
+	if len(segments) == 0 {
+		if len(s.decls) > 0 {
+			header = strings.TrimRight(header, " \t\r\n") + "\n"
+		}
+	} else if segments[0].decl != 0 {
+		header = strings.TrimRight(header, " \t\r\n") + "\n\n"
+	}
+	out.WriteString(header)
+	for i, seg := range segments {
+		if i > 0 {
+			prev := segments[i-1].decl
+			if prev >= 0 && seg.decl == prev+1 {
+				out.WriteString(string(s.src[s.decls[prev].end:s.decls[seg.decl].start]))
+			} else {
+				out.WriteString("\n\n")
+			}
+		}
+		if spans != nil {
+			for _, span := range seg.spans {
+				span.start += out.Len()
+				span.end += out.Len()
+				*spans = append(*spans, span)
+			}
+		}
+		out.WriteString(seg.text)
+	}
+	if len(segments) > 0 {
+		if last := segments[len(segments)-1].decl; last == len(s.decls)-1 {
+			out.WriteString(string(s.src[s.decls[last].end:]))
+		} else {
+			out.WriteString("\n")
+		}
+	}
+
+	src := out.String()
+	if formatErr != nil {
+		writeString(w, src)
+		return formatErr
+	}
+	// The imports are before the declarations, the ranges move back by the
+	// removed text.
+	if pruned, err := dropUnusedImports("", s.file, src); err == nil {
+		if spans != nil {
+			for i := recorded; i < len(*spans); i++ {
+				(*spans)[i].start -= len(src) - len(pruned)
+				(*spans)[i].end -= len(src) - len(pruned)
+			}
+		}
+		src = pruned
+	}
+	writeString(w, src)
+	return nil
+}
+
+// renderDecls renders and formats new or changed declarations, the output
+// ranges of the nodes are moved to the formatted text. The rendered text is
+// returned with the error if it could not be formatted.
+func (g *Generator) renderDecls(unit *Group) (string, []nodeSpan, error) {
+	buf := pool.Get()
+	defer buf.Free()
+	var spans []nodeSpan
+	unit.render(&RenderContext{w: buf, gen: g, spans: &spans})
+	rendered := buf.String()
+
+	text := strings.TrimLeft(rendered, "\n")
+	trimmed := len(rendered) - len(text)
+	text = strings.TrimRight(text, "\n")
+	for i := range spans {
+		spans[i].start = min(max(spans[i].start-trimmed, 0), len(text))
+		spans[i].end = min(max(spans[i].end-trimmed, 0), len(text))
+	}
+	formatted, err := formatDecls(text)
+	if err != nil {
+		return text, spans, err
+	}
+	if formatted == text {
+		return text, spans, nil
+	}
+	if moved, ok := moveSpans(text, formatted, spans); ok {
+		return formatted, moved, nil
+	}
+	// The tokens don't match, each item covers the whole text.
+	spans = spans[:0]
+	for _, n := range unit.items {
+		spans = append(spans, nodeSpan{node: n, group: unit, start: 0, end: len(formatted)})
+	}
+	return formatted, spans, nil
+}
+
+// moveSpans moves output ranges of src to the formatted text out. The
+// formatting only changes the spaces between tokens, so a range is moved to
+// the range of the same tokens. Ranges without tokens, like of a comment,
+// are dropped.
+func moveSpans(src, out string, spans []nodeSpan) ([]nodeSpan, bool) {
+	from, to := scanTokens(src), scanTokens(out)
+	if len(from) != len(to) {
+		return nil, false
+	}
+	for i := range from {
+		if from[i].tok != to[i].tok || from[i].lit != to[i].lit {
+			return nil, false
+		}
+	}
+	var moved []nodeSpan
+	for _, s := range spans {
+		first := sort.Search(len(from), func(i int) bool { return from[i].start >= s.start })
+		last := sort.Search(len(from), func(i int) bool { return from[i].end > s.end }) - 1
+		if first > last {
+			continue
+		}
+		moved = append(moved, nodeSpan{node: s.node, start: to[first].start, end: to[last].end})
+	}
+	return moved, true
+}
+
+// sourceToken is a token of a Go source and its byte range.
+type sourceToken struct {
+	tok        token.Token
+	lit        string
+	start, end int
+}
+
+// scanTokens returns the tokens of a Go source, without comments and
+// semicolons, which are changed by the formatting.
+func scanTokens(src string) []sourceToken {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, 0)
+	var tokens []sourceToken
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return tokens
+		}
+		if tok == token.SEMICOLON {
+			continue
+		}
+		start := file.Offset(pos)
+		text := lit
+		if text == "" {
+			text = tok.String()
+		}
+		tokens = append(tokens, sourceToken{tok: tok, lit: lit, start: start, end: start + len(text)})
+	}
+}
+
+func (d sourceDecl) text(src []byte) string {
+	return string(src[d.start:d.end])
+}
+
+func (s *sourceFile) offset(pos token.Pos) int {
+	return s.fset.Position(pos).Offset
+}
+
+// formatDecls formats rendered declarations.
+func formatDecls(text string) (string, error) {
+	out, err := format.Source([]byte(text))
+	if err != nil {
+		return "", fmt.Errorf("format changed code: %s", err)
+	}
+	return string(out), nil
+}
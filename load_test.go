@@ -0,0 +1,238 @@
+package gg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const loadSource = `// Package store keeps users.
+package store
+
+import (
+	"context"
+)
+
+// User is a user.
+type User struct {
+	ID   int    // primary key
+	Name string ` + "`json:\"name\"`" + `
+}
+
+// Floating comment, kept as is.
+
+// Get   returns the user.
+func   Get(ctx context.Context, id int) (*User, error) {
+	return nil,    nil // not found
+}
+
+func (u *User) Validate() error {
+	return nil
+}
+
+var (
+	a = 1 // one
+	b = 2
+)
+`
+
+func TestLoad_Unchanged(t *testing.T) {
+	gen, err := Load("store.go", []byte(loadSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output := gen.String(); output != loadSource {
+		t.Errorf("Expected the file to be kept byte for byte, got:\n%s", output)
+	}
+	if output := gen.Clone().String(); output != loadSource {
+		t.Errorf("Expected the clone to be kept byte for byte, got:\n%s", output)
+	}
+}
+
+func TestLoad_Edit(t *testing.T) {
+	gen, err := Load("store.go", []byte(loadSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, ok := gen.Body().FindType("User").(*istruct)
+	if !ok {
+		t.Fatalf("Expected a struct, got %T", gen.Body().FindType("User"))
+	}
+	user.AddField("CreatedAt", gen.P("time").Type("Time"))
+
+	validate := gen.Body().FindMethod("User", "Validate")
+	body := validate.Body()
+	body.InsertBefore(body.items[0],
+		If(`u.Name == ""`).AddBody(
+			Return(gen.P("errors").Call("New", Lit("empty name"))),
+		),
+	)
+	gen.Body().NewFunction("Delete").
+		AddParameter("id", "int").
+		AddResult("", "error").
+		AddBody(Return("nil"))
+
+	expected := `// Package store keeps users.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// User is a user.
+type User struct {
+	ID        int    // primary key
+	Name      string ` + "`json:\"name\"`" + `
+	CreatedAt time.Time
+}
+
+// Floating comment, kept as is.
+
+// Get   returns the user.
+func   Get(ctx context.Context, id int) (*User, error) {
+	return nil,    nil // not found
+}
+
+func (u *User) Validate() error {
+	if u.Name == "" {
+		return errors.New("empty name")
+	}
+	return nil
+}
+
+var (
+	a = 1 // one
+	b = 2
+)
+
+func Delete(id int) error {
+	return nil
+}
+`
+	if output := gen.String(); output != expected {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+}
+
+func TestLoad_Remove(t *testing.T) {
+	gen, err := Load("store.go", []byte(loadSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.Body().Remove(gen.Body().FindFunction("Get"))
+	gen.Body().Remove(gen.Body().FindVar("a"))
+
+	output := gen.String()
+	if strings.Contains(output, "func   Get") || strings.Contains(output, "a = 1") {
+		t.Errorf("Expected Get and the vars to be removed, got:\n%s", output)
+	}
+	if !strings.HasSuffix(output, "func (u *User) Validate() error {\n\treturn nil\n}\n") {
+		t.Errorf("Unexpected end of file:\n%s", output)
+	}
+	if !strings.Contains(output, "}\n\nfunc (u *User) Validate()") {
+		t.Errorf("Expected Validate to follow User, got:\n%s", output)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.go")
+	if err := os.WriteFile(path, []byte("package store\n\nfunc Get() {\n\tprintln()\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.SetPackage("repo")
+	gen.Body().FindFunction("Get").AddBody(Return())
+	if err := gen.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "package repo\n\nfunc Get() {\n\tprintln()\n\treturn\n}\n" {
+		t.Errorf("Unexpected output:\n%s", data)
+	}
+
+	if _, err := Load("bad.go", []byte("package store\n\nfunc {")); err == nil || !strings.HasPrefix(err.Error(), "load file bad.go: ") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+}
+
+func TestLoad_TypeCheck(t *testing.T) {
+	gen, err := Load("store.go", []byte("package store\n\nfunc Get() int {\n\treturn \"x\"\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := Return(Lit(1))
+	gen.Body().NewFunction("Put").AddParameter("s", "string").AddBody(bad)
+
+	var tcErr *TypeCheckError
+	if err := gen.TypeCheck(); !errors.As(err, &tcErr) || len(tcErr.Diagnostics) != 2 {
+		t.Fatalf("Expected 2 diagnostics, got %v", err)
+	}
+	get := gen.Body().FindFunction("Get")
+	if d := tcErr.Diagnostics[0]; !slices.Contains(d.Nodes, Node(get)) {
+		t.Errorf("Expected the unchanged function Get, got %v", d.Nodes)
+	}
+	if d := tcErr.Diagnostics[1]; !slices.Contains(d.Nodes, Node(bad)) || DeclName(d.Nodes[len(d.Nodes)-1]) != "Put" {
+		t.Errorf("Expected the return statement of Put, got %v", d.Nodes)
+	}
+	lines := strings.Split(gen.String(), "\n")
+	if p := tcErr.Diagnostics[1].Pos; p.Line < 1 || !strings.Contains(lines[p.Line-1], "return 1") {
+		t.Errorf("Expected the position of the return statement, got %v", p)
+	}
+}
+
+func TestLoad_EditLineComment(t *testing.T) {
+	src := "package store\n\ntype User struct{}\n\nfunc (u *User) Validate() error {\n\treturn nil // ok\n}\n"
+	gen, err := Load("store.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.Body().FindMethod("User", "Validate").AddParameter("n", "int")
+
+	path := filepath.Join(t.TempDir(), "store.go")
+	if err := gen.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	expected := "package store\n\ntype User struct{}\n\nfunc (u *User) Validate(n int) error {\n\treturn nil // ok\n}\n"
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestLoad_FormatError(t *testing.T) {
+	gen, err := Load("store.go", []byte("package store\n\nfunc Get() {}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.Body().FindFunction("Get").AddBody("x :=")
+
+	path := filepath.Join(t.TempDir(), "store.go")
+	if err := gen.WriteFile(path); err == nil || !strings.Contains(err.Error(), "format changed code") {
+		t.Fatalf("Expected a format error, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the file not to be written, got %v", err)
+	}
+}
+
+func TestLoad_UnusedImports(t *testing.T) {
+	src := "package store\n\nimport (\n\t\"os\"\n\t\"strconv\"\n)\n\nfunc Parse(s string) int {\n\tn, _ := strconv.Atoi(s)\n\treturn n\n}\n\nvar out = os.Stdout\n"
+	gen, err := Load("store.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.Body().Remove(gen.Body().FindFunction("Parse"))
+
+	expected := "package store\n\nimport (\n\t\"os\"\n)\n\nvar out = os.Stdout\n"
+	if output := gen.String(); output != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
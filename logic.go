@@ -3,8 +3,9 @@ package gg
 import "io"
 
 type iif struct {
-	judge Node
-	body  *Group
+	judge    Node
+	body     *Group
+	elseNode Node
 }
 
 func If(judge interface{}) *iif {
@@ -17,6 +18,10 @@ func (i *iif) render(w io.Writer) {
 	writeString(w, "if ")
 	i.judge.render(w)
 	i.body.render(w)
+	if i.elseNode != nil {
+		writeString(w, " else ")
+		i.elseNode.render(w)
+	}
 }
 
 func (i *iif) AddBody(node ...interface{}) *iif {
@@ -24,6 +29,21 @@ func (i *iif) AddBody(node ...interface{}) *iif {
 	return i
 }
 
+// Else will add an else branch and return its body.
+func (i *iif) Else(node ...interface{}) *Group {
+	body := newGroup("{\n", "\n}", "\n")
+	body.append(node...)
+	i.elseNode = body
+	return body
+}
+
+// ElseIf will add an `else if` branch and return it for further chaining.
+func (i *iif) ElseIf(judge interface{}) *iif {
+	ei := If(judge)
+	i.elseNode = ei
+	return ei
+}
+
 type ifor struct {
 	judge Node
 	body  *Group
@@ -60,6 +80,7 @@ func (i *icase) render(w io.Writer) {
 		i.judge.render(w)
 		writeString(w, ":")
 	}
+	defer enterBlock(w)()
 	i.body.render(w)
 }
 
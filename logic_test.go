@@ -40,3 +40,23 @@ default:
 
 	compareAST(t, expected, buf.String())
 }
+
+func TestIfElse(t *testing.T) {
+	buf := pool.Get()
+	defer buf.Free()
+
+	expected := `
+if a {
+	print("a")
+} else if b {
+	print("b")
+} else {
+	print("c")
+}
+`
+	i := If(String("a")).AddBody(String(`print("a")`))
+	i.ElseIf(String("b")).AddBody(String(`print("b")`)).Else(String(`print("c")`))
+	i.render(buf)
+
+	compareAST(t, expected, buf.String())
+}
@@ -0,0 +1,396 @@
+package gg
+
+import (
+	"fmt"
+	"go/build/constraint"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MergePolicy decides what Merge does with declarations which are declared
+// by both generators.
+type MergePolicy int
+
+const (
+	// MergeAppend appends all declarations, even the duplicated ones.
+	MergeAppend MergePolicy = iota
+	// MergeError returns a *MergeConflictError on duplicated declarations,
+	// and leaves the generator unchanged.
+	MergeError
+	// MergeKeepFirst keeps the existing declarations and drops the
+	// duplicated ones of the other generator.
+	MergeKeepFirst
+	// MergeReplace replaces the existing declarations with the ones of the
+	// other generator.
+	MergeReplace
+	// MergeDedup drops the duplicated declarations which are identical to
+	// the existing ones, and fails like MergeError on the others.
+	MergeDedup
+)
+
+// MergeConflict describes a declaration which is declared by both
+// generators.
+type MergeConflict struct {
+	// Name is the declared name, like `ptr` or `User.Validate` for methods.
+	Name string
+	// Existing and Incoming are the declarations of the target and the
+	// merged generator.
+	Existing Node
+	Incoming Node
+	// Identical reports whether both declarations render the same code.
+	Identical bool
+	// Partial reports whether the declarations declare different names,
+	// like a const block of which only some names are redeclared. It
+	// cannot be resolved by MergeKeepFirst, MergeReplace or MergeDedup
+	// without dropping the other names.
+	Partial bool
+}
+
+func (c MergeConflict) String() string {
+	switch {
+	case c.Identical:
+		return fmt.Sprintf("%s %s (identical)", declKind(c.Incoming), c.Name)
+	case c.Partial:
+		return fmt.Sprintf("%s %s (partial)", declKind(c.Incoming), c.Name)
+	}
+	return fmt.Sprintf("%s %s", declKind(c.Incoming), c.Name)
+}
+
+// MergeConflictError is returned by MergeWith when declarations conflict.
+type MergeConflictError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *MergeConflictError) Error() string {
+	names := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		names = append(names, c.String())
+	}
+	return "merge: redeclared " + strings.Join(names, ", ")
+}
+
+// Merge merges another Generator's body and imports into this one.
+// This is useful when multiple generators need to output to the same file.
+// A copy of the other generator's body is appended to this generator's body,
+// so other is not changed and could be merged into several generators.
+// Import paths are merged, with alias conflicts resolved automatically.
+// All PackageRef in the merged generator will be updated to use the correct aliases.
+//
+// Both generators must generate the same package, otherwise an error is
+// returned and this generator is not changed. Package name, import path and
+// header are taken from other while they are not set here, headers which
+// differ are combined, and build constraints are combined with `&&`. Blank
+// and dot imports are merged too.
+//
+// Duplicated declarations are appended, use MergeWith to handle them.
+func (g *Generator) Merge(other *Generator) error {
+	_, err := g.MergeWith(other, MergeAppend)
+	return err
+}
+
+// MergeWith merges another Generator like Merge, and handles the
+// declarations declared by both generators with the policy. It returns all
+// conflicts found, the error is a *MergeConflictError if the policy doesn't
+// allow some of them. Partial conflicts, like a const block of which only
+// some names are redeclared, are only allowed by MergeAppend.
+//
+// Example:
+//
+//	conflicts, err := gen.MergeWith(helpers, gg.MergeDedup)
+//	if err != nil {
+//		return err // merge: redeclared func ptr
+//	}
+func (g *Generator) MergeWith(other *Generator, policy MergePolicy) ([]MergeConflict, error) {
+	if other == nil {
+		return nil, nil
+	}
+	if err := g.checkMergePackage(other); err != nil {
+		return nil, err
+	}
+	buildConstraint, err := mergeBuildConstraints(g.buildConstraint, other.buildConstraint)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the import state, so it could be restored if the merge fails.
+	packages, aliasToPath, registeredPaths := maps.Clone(g.packages), maps.Clone(g.aliasToPath), slices.Clone(g.registeredPaths)
+
+	// Build alias mapping: old alias -> new alias
+	aliasMapping := make(map[string]string)
+
+	// Merge imports and build mapping
+	for importPath, pkg := range other.packages {
+		oldAlias := pkg.alias
+
+		if importPath == g.importPath && importPath != "" {
+			// References to this package are not qualified
+			aliasMapping[oldAlias] = ""
+		} else if _, exists := g.packages[importPath]; !exists {
+			// Package not yet registered
+			// Check if the alias is already used by another package
+			if existingPath, aliasUsed := g.aliasToPath[oldAlias]; aliasUsed && existingPath != importPath {
+				// Alias conflict, let P() auto-resolve
+				newPkg := g.P(importPath)
+				aliasMapping[oldAlias] = newPkg.alias
+			} else {
+				// No conflict, preserve the original alias
+				newPkg := g.PAlias(importPath, oldAlias)
+				aliasMapping[oldAlias] = newPkg.alias
+			}
+		} else {
+			// Package already exists, use existing alias
+			aliasMapping[oldAlias] = g.packages[importPath].alias
+		}
+	}
+
+	// Update all PackageRef in a copy of other's body to point to this
+	// generator and use the new aliases, so other is left unchanged
+	body := other.g.Clone()
+	updatePackageRefs(body, g, aliasMapping)
+	// The merged file has a single package clause.
+	removePackageClauses(body)
+
+	conflicts := findConflicts(g.g, body)
+	var failed []MergeConflict
+	for _, c := range conflicts {
+		switch policy {
+		case MergeError:
+			failed = append(failed, c)
+		case MergeDedup:
+			if !c.Identical {
+				failed = append(failed, c)
+			}
+		case MergeKeepFirst, MergeReplace:
+			if c.Partial {
+				failed = append(failed, c)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		g.packages, g.aliasToPath, g.registeredPaths = packages, aliasToPath, registeredPaths
+		return conflicts, &MergeConflictError{Conflicts: failed}
+	}
+	if policy == MergeReplace {
+		if err := checkReplace(g.g, body, conflicts); err != nil {
+			g.packages, g.aliasToPath, g.registeredPaths = packages, aliasToPath, registeredPaths
+			return conflicts, err
+		}
+	}
+
+	for _, c := range conflicts {
+		switch policy {
+		case MergeKeepFirst, MergeDedup:
+			body.Remove(c.Incoming)
+		case MergeReplace:
+			if err := replaceDecl(g.g, body, c.Existing, c.Incoming); err != nil {
+				// Not reached, the declarations are checked first.
+				return conflicts, err
+			}
+		}
+	}
+
+	// Merge body - append other's body content to this generator
+	g.g.Append(body)
+
+	g.mergeFileHeader(other, buildConstraint)
+	return conflicts, nil
+}
+
+// checkMergePackage reports an error if other generates another package.
+func (g *Generator) checkMergePackage(other *Generator) error {
+	name, otherName := g.declaredPackage(), other.declaredPackage()
+	if name != "" && otherName != "" && name != otherName {
+		return fmt.Errorf("merge: cannot merge package %s into package %s", otherName, name)
+	}
+	if g.importPath != "" && other.importPath != "" && g.importPath != other.importPath {
+		return fmt.Errorf("merge: cannot merge import path %q into %q", other.importPath, g.importPath)
+	}
+	return nil
+}
+
+// mergeFileHeader takes over the file level settings of other.
+func (g *Generator) mergeFileHeader(other *Generator, buildConstraint string) {
+	if g.declaredPackage() == "" {
+		g.packageName = other.declaredPackage()
+	}
+	if g.importPath == "" && other.importPath != "" {
+		g.SetImportPath(other.importPath)
+	}
+	g.buildConstraint = buildConstraint
+
+	// Keep every header line once, like two "Code generated by" lines of
+	// different tools.
+	lines := strings.Split(g.headerComment, "\n")
+	for _, line := range strings.Split(other.headerComment, "\n") {
+		if line != "" && !slices.Contains(lines, line) {
+			lines = append(lines, line)
+		}
+	}
+	g.headerComment = strings.TrimPrefix(strings.Join(lines, "\n"), "\n")
+
+	for importPath, name := range other.sideImports {
+		if name == "." {
+			g.ImportDot(importPath)
+		} else {
+			g.ImportBlank(importPath)
+		}
+	}
+}
+
+// mergeBuildConstraints returns the constraint matching both expressions.
+func mergeBuildConstraints(a, b string) (string, error) {
+	switch {
+	case b == "" || a == b:
+		return a, nil
+	case a == "":
+		return b, nil
+	}
+	x, err := constraint.Parse("//go:build " + a)
+	if err != nil {
+		return "", fmt.Errorf("merge: invalid build constraint %q: %w", a, err)
+	}
+	y, err := constraint.Parse("//go:build " + b)
+	if err != nil {
+		return "", fmt.Errorf("merge: invalid build constraint %q: %w", b, err)
+	}
+	return (&constraint.AndExpr{X: x, Y: y}).String(), nil
+}
+
+// removePackageClauses removes the package clauses added by AddPackage.
+func removePackageClauses(body *Group) {
+	var parents []*Group
+	eachDecl(body, func(parent *Group, _ int, n Node) bool {
+		if _, ok := n.(*ipackage); ok && !slices.Contains(parents, parent) {
+			parents = append(parents, parent)
+		}
+		return true
+	})
+	for _, parent := range parents {
+		parent.items = slices.DeleteFunc(parent.items, func(n Node) bool {
+			_, ok := n.(*ipackage)
+			return ok
+		})
+	}
+}
+
+// findConflicts returns the declarations of incoming which are declared in
+// existing too.
+func findConflicts(existing, incoming *Group) []MergeConflict {
+	declared := make(map[string]Node)
+	eachDecl(existing, func(_ *Group, _ int, n Node) bool {
+		for _, name := range declNames(n) {
+			if _, ok := declared[name]; !ok {
+				declared[name] = n
+			}
+		}
+		return true
+	})
+
+	var conflicts []MergeConflict
+	eachDecl(incoming, func(_ *Group, _ int, n Node) bool {
+		names := declNames(n)
+		for _, name := range names {
+			if prev, ok := declared[name]; ok {
+				conflicts = append(conflicts, MergeConflict{
+					Name:      name,
+					Existing:  prev,
+					Incoming:  n,
+					Identical: renderString(prev) == renderString(n),
+					Partial:   !sameNames(declNames(prev), names),
+				})
+				// Report every declaration only once.
+				break
+			}
+		}
+		return true
+	})
+	return conflicts
+}
+
+// sameNames reports whether both lists hold the same names.
+func sameNames(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// checkReplace reports an error if a conflict cannot be replaced, like an
+// existing declaration which would be replaced twice.
+func checkReplace(existing, incoming *Group, conflicts []MergeConflict) error {
+	replaced := make(map[Node]bool, len(conflicts))
+	for _, c := range conflicts {
+		if replaced[c.Existing] {
+			return fmt.Errorf("merge: cannot replace %s %s twice", declKind(c.Existing), c.Name)
+		}
+		replaced[c.Existing] = true
+		_, _, okOld := findItem(existing, c.Existing)
+		_, _, okNew := findItem(incoming, c.Incoming)
+		if !okOld || !okNew {
+			return fmt.Errorf("merge: cannot replace %s %s: declaration not found", declKind(c.Existing), c.Name)
+		}
+	}
+	return nil
+}
+
+// replaceDecl moves the incoming declaration with its comments from the
+// incoming group to the place of the existing declaration.
+func replaceDecl(existing, incoming *Group, old, decl Node) error {
+	dst, dstIdx, ok := findItem(existing, old)
+	if !ok {
+		return fmt.Errorf("merge: cannot replace %s %s: declaration not found", declKind(old), DeclName(old))
+	}
+	src, idx, ok := findItem(incoming, decl)
+	if !ok {
+		return fmt.Errorf("merge: cannot replace %s %s: declaration not found", declKind(decl), DeclName(decl))
+	}
+	start := leadingComments(src, idx)
+	items := slices.Clone(src.items[start : idx+1])
+	src.items = slices.Delete(src.items, start, idx+1)
+
+	start = leadingComments(dst, dstIdx)
+	dst.items = slices.Replace(dst.items, start, dstIdx+1, items...)
+	return nil
+}
+
+// declNames returns the package level names declared by a node, methods
+// are named like `Type.Method`. Blank identifiers and init functions could
+// be declared many times, so they are not included.
+func declNames(n Node) []string {
+	var names []string
+	switch v := n.(type) {
+	case *ifunction:
+		if v.receiver == nil && v.name == "init" {
+			return nil
+		}
+		names = []string{DeclName(v)}
+	case *iconst:
+		names = declaredNames(v.items)
+	case *ivar:
+		names = declaredNames(v.items)
+	default:
+		if name := typeName(n); name != "" {
+			names = []string{name}
+		}
+	}
+	return slices.DeleteFunc(names, func(name string) bool {
+		return name == "_" || name == ""
+	})
+}
+
+// declKind returns the keyword of a declaration.
+func declKind(n Node) string {
+	switch v := n.(type) {
+	case *ifunction:
+		if v.receiver != nil {
+			return "method"
+		}
+		return "func"
+	case *iconst:
+		return "const"
+	case *ivar:
+		return "var"
+	}
+	return "type"
+}
@@ -0,0 +1,232 @@
+package gg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newHelperGenerator(body string) *Generator {
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().AddLineComment("ptr returns a pointer to v.")
+	gen.Body().NewFunction("ptr").
+		AddTypeParameter("T", "any").
+		AddParameter("v", "T").
+		AddResult("", "*T").
+		AddBody(String(body))
+	gen.Body().NewVar().AddTypedField("_", "fmt.Stringer", "nil")
+	gen.Body().NewFunction("init").AddBody(String("setup()"))
+	return gen
+}
+
+func TestMergeWith_Policies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   MergePolicy
+		incoming string
+		count    int
+		err      bool
+	}{
+		{"append", MergeAppend, "return &v", 2, false},
+		{"error", MergeError, "return &v", 1, true},
+		{"keep first", MergeKeepFirst, "panic(1)", 1, false},
+		{"replace", MergeReplace, "panic(1)", 1, false},
+		{"dedup identical", MergeDedup, "return &v", 1, false},
+		{"dedup different", MergeDedup, "panic(1)", 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := newHelperGenerator("return &v")
+			conflicts, err := gen.MergeWith(newHelperGenerator(tt.incoming), tt.policy)
+
+			if (err != nil) != tt.err {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if len(conflicts) != 1 || conflicts[0].Name != "ptr" {
+				t.Fatalf("Expected one conflict for ptr, got %v", conflicts)
+			}
+
+			output := gen.String()
+			if got := strings.Count(output, "func ptr"); got != tt.count {
+				t.Errorf("Expected %d ptr functions, got %d:\n%s", tt.count, got, output)
+			}
+			// init functions and blank vars are never conflicts.
+			wantInit := 2
+			if tt.err {
+				wantInit = 1
+			}
+			if got := strings.Count(output, "func init"); got != wantInit {
+				t.Errorf("Expected %d init functions, got %d:\n%s", wantInit, got, output)
+			}
+			if got := strings.Count(output, "// ptr returns"); got != tt.count {
+				t.Errorf("Expected comments to follow declarations, got:\n%s", output)
+			}
+			if tt.policy == MergeReplace && !strings.Contains(output, "panic(1)") {
+				t.Errorf("Expected replaced function, got:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestMergeWith_ErrorKeepsTarget(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().NewStruct("Option")
+
+	other := New()
+	other.SetPackage("main")
+	other.Body().AddType("Option", other.P("github.com/example/opts").Type("Option"))
+	other.Body().NewConst().AddField("Version", Lit(1))
+	gen.Body().NewVar().AddField("Version", Lit(2))
+
+	_, err := gen.MergeWith(other, MergeError)
+	var conflictErr *MergeConflictError
+	if !errors.As(err, &conflictErr) || len(conflictErr.Conflicts) != 2 {
+		t.Fatalf("Expected two conflicts, got %v", err)
+	}
+	if err.Error() != "merge: redeclared type Option, const Version" {
+		t.Errorf("Unexpected error message: %s", err)
+	}
+
+	output := gen.String()
+	if strings.Contains(output, "opts") || strings.Count(output, "Option") != 1 {
+		t.Errorf("Target should be unchanged, got:\n%s", output)
+	}
+}
+
+func TestMergeWith_PartialConflict(t *testing.T) {
+	for _, policy := range []MergePolicy{MergeKeepFirst, MergeReplace, MergeDedup} {
+		gen := New()
+		gen.SetPackage("main")
+		gen.Body().NewConst().AddField("A", Lit(1))
+
+		other := New()
+		other.SetPackage("main")
+		other.Body().NewConst().AddField("A", Lit(1)).AddField("B", Lit(2))
+
+		conflicts, err := gen.MergeWith(other, policy)
+		var conflictErr *MergeConflictError
+		if !errors.As(err, &conflictErr) || len(conflicts) != 1 || !conflicts[0].Partial {
+			t.Fatalf("Policy %d: expected a partial conflict, got %v", policy, err)
+		}
+		if err.Error() != "merge: redeclared const A (partial)" {
+			t.Errorf("Unexpected error message: %s", err)
+		}
+		if output := gen.String(); strings.Contains(output, "B") {
+			t.Errorf("Target should be unchanged, got:\n%s", output)
+		}
+	}
+
+	// The incoming block is appended as it is.
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().NewConst().AddField("A", Lit(1))
+	other := New()
+	other.SetPackage("main")
+	other.Body().NewConst().AddField("A", Lit(1)).AddField("B", Lit(2))
+	if _, err := gen.MergeWith(other, MergeAppend); err != nil || !strings.Contains(gen.String(), "B") {
+		t.Errorf("Expected the block to be appended, got %v:\n%s", err, gen.String())
+	}
+}
+
+func TestMergeWith_ReplaceTwice(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().NewFunction("F").AddBody("return")
+
+	other := New()
+	other.SetPackage("main")
+	other.Body().NewFunction("F").AddBody("panic(1)")
+	other.Body().NewFunction("F").AddBody("panic(2)")
+
+	_, err := gen.MergeWith(other, MergeReplace)
+	if err == nil || err.Error() != "merge: cannot replace func F twice" {
+		t.Fatalf("Expected an error, got %v", err)
+	}
+	if output := gen.String(); strings.Contains(output, "panic") {
+		t.Errorf("Target should be unchanged, got:\n%s", output)
+	}
+}
+
+func TestMerge_PackageMismatch(t *testing.T) {
+	gen := New()
+	gen.SetPackage("bar")
+	gen.Body().NewFunction("Bar")
+
+	other := New()
+	other.SetPackage("foo")
+	other.P("context")
+	other.Body().NewFunction("Foo")
+
+	err := gen.Merge(other)
+	if err == nil || err.Error() != "merge: cannot merge package foo into package bar" {
+		t.Fatalf("Expected package mismatch error, got %v", err)
+	}
+	if output := gen.String(); strings.Contains(output, "Foo") || strings.Contains(output, "context") {
+		t.Errorf("Target should be unchanged, got:\n%s", output)
+	}
+
+	// The package clause of the Group API is checked too.
+	other = New()
+	other.Body().AddPackage("foo")
+	if err := gen.Merge(other); err == nil {
+		t.Errorf("Expected package mismatch error for AddPackage")
+	}
+
+	other = New()
+	other.SetImportPath("github.com/example/foo")
+	gen.SetImportPath("github.com/example/bar")
+	if err := gen.Merge(other); err == nil {
+		t.Errorf("Expected import path mismatch error")
+	}
+}
+
+func TestMerge_FileHeader(t *testing.T) {
+	gen := New()
+	gen.SetHeader("Code generated by enumgen. DO NOT EDIT.")
+	gen.SetBuildConstraint("linux")
+	gen.ImportBlank("embed")
+	gen.Body().NewFunction("A")
+
+	other := New()
+	other.SetPackage("models")
+	other.SetHeader("Code generated by enumgen. DO NOT EDIT.\nCode generated by mockgen. DO NOT EDIT.")
+	other.SetBuildConstraint("amd64 || arm64")
+	other.ImportDot("math")
+	other.Body().AddPackage("models")
+	other.Body().NewFunction("B")
+
+	if err := gen.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `//go:build linux && (amd64 || arm64)
+
+// Code generated by enumgen. DO NOT EDIT.
+// Code generated by mockgen. DO NOT EDIT.
+package models
+
+import (
+	_ "embed"
+	. "math"
+)
+
+func A()
+func B()
+`
+	output := gen.String()
+	compareAST(t, expected, output)
+	if !strings.HasPrefix(output, expected[:strings.Index(expected, "package")]) {
+		t.Errorf("Unexpected file header:\n%s", output)
+	}
+}
+
+func TestMerge_InvalidBuildConstraint(t *testing.T) {
+	gen := New().SetBuildConstraint("linux &&")
+	other := New().SetBuildConstraint("amd64")
+	if err := gen.Merge(other); err == nil || !strings.Contains(err.Error(), "invalid build constraint") {
+		t.Errorf("Expected invalid build constraint error, got %v", err)
+	}
+}
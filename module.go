@@ -0,0 +1,177 @@
+package gg
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Module holds the packages of a generated module, like a service scaffold,
+// and writes them together with the go.mod file.
+//
+// Example:
+//
+//	mod := gg.NewModule("github.com/example/svc").SetGoVersion("1.21")
+//	models := mod.Package("models")
+//	models.File("user.go").Body().NewStruct("User")
+//
+//	handler := mod.Package("internal/handler").File("user.go")
+//	handler.Body().NewFunction("Get").AddResult("", models.Ref(handler).Ptr("User"))
+//
+//	err := mod.WriteDir("./svc")
+type Module struct {
+	path      string
+	goVersion string
+	requires  [][2]string // module path and version
+
+	packages map[string]*GoPackage // relative dir -> package
+	dirs     []string              // relative dirs in creation order
+}
+
+// NewModule creates a module with the module path.
+func NewModule(modulePath string) *Module {
+	return &Module{
+		path:     modulePath,
+		packages: make(map[string]*GoPackage),
+	}
+}
+
+// Path returns the module path.
+func (m *Module) Path() string {
+	return m.path
+}
+
+// SetGoVersion sets the go directive of go.mod, like `1.21`, which is the
+// Go version targeted by all packages too, see Generator.SetGoVersion. It
+// panics if the version is invalid.
+func (m *Module) SetGoVersion(version string) *Module {
+	m.goVersion = normalizeGoVersion(version)
+	for _, dir := range m.dirs {
+		m.packages[dir].SetGoVersion(version)
+	}
+	return m
+}
+
+// Require adds a requirement to go.mod, a module required again replaces
+// the previous version.
+func (m *Module) Require(modulePath, version string) *Module {
+	for i, r := range m.requires {
+		if r[0] == modulePath {
+			m.requires[i][1] = version
+			return m
+		}
+	}
+	m.requires = append(m.requires, [2]string{modulePath, version})
+	return m
+}
+
+// Package returns the package in the directory relative to the module root,
+// like `internal/handler`, which is created on the first call. The package
+// name is derived from the directory, and the root package is `""` or `"."`.
+func (m *Module) Package(dir string) *GoPackage {
+	dir = m.cleanDir(dir)
+	if pkg, ok := m.packages[dir]; ok {
+		return pkg
+	}
+	importPath := m.importPath(dir)
+	return m.PackageNamed(dir, resolvePackageAlias(importPath, nil))
+}
+
+// PackageNamed returns the package in the directory like Package, and uses
+// name for a new package, like `main` for `cmd/server`. It panics if the
+// package exists with another name.
+func (m *Module) PackageNamed(dir, name string) *GoPackage {
+	dir = m.cleanDir(dir)
+	if pkg, ok := m.packages[dir]; ok {
+		if pkg.name != name {
+			panic(fmt.Sprintf("package %q already exists with name %s, cannot use %s", dir, pkg.name, name))
+		}
+		return pkg
+	}
+	pkg := NewPackage(name, m.importPath(dir)).SetGoVersion(m.goVersion)
+	m.packages[dir] = pkg
+	m.dirs = append(m.dirs, dir)
+	return pkg
+}
+
+// Packages returns the relative directories of the packages in creation
+// order, the root package is `.`.
+func (m *Module) Packages() []string {
+	return slices.Clone(m.dirs)
+}
+
+// cleanDir normalizes a relative directory, it panics if the directory is
+// outside of the module.
+func (m *Module) cleanDir(dir string) string {
+	dir = path.Clean(filepath.ToSlash(dir))
+	if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		panic(fmt.Sprintf("package dir %q is outside of module %s", dir, m.path))
+	}
+	return dir
+}
+
+func (m *Module) importPath(dir string) string {
+	if dir == "." {
+		return m.path
+	}
+	return m.path + "/" + dir
+}
+
+// GoMod returns the content of the go.mod file.
+func (m *Module) GoMod() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "module %s\n", m.path)
+	if m.goVersion != "" {
+		fmt.Fprintf(&b, "\ngo %s\n", m.goVersion)
+	}
+	switch len(m.requires) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&b, "\nrequire %s %s\n", m.requires[0][0], m.requires[0][1])
+	default:
+		b.WriteString("\nrequire (\n")
+		for _, r := range m.requires {
+			fmt.Fprintf(&b, "\t%s %s\n", r[0], r[1])
+		}
+		b.WriteString(")\n")
+	}
+	return []byte(b.String())
+}
+
+// WriteDir checks all packages, then writes go.mod and the files of every
+// package into the root directory.
+func (m *Module) WriteDir(root string) error {
+	_, err := m.WriteDirWith(root, WriteOptions{})
+	return err
+}
+
+// WriteDirWith writes the module like WriteDir, every file is written with
+// the options. It returns the results of go.mod and all files.
+func (m *Module) WriteDirWith(root string, opts WriteOptions) ([]WriteResult, error) {
+	for _, dir := range m.dirs {
+		if err := m.packages[dir].Check(); err != nil {
+			return nil, err
+		}
+	}
+	if !opts.DryRun {
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return nil, fmt.Errorf("create dir %s: %s", root, err)
+		}
+	}
+	res, err := writeFile(filepath.Join(root, "go.mod"), m.GoMod(), opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []WriteResult{res}
+	for _, dir := range m.dirs {
+		written, err := m.packages[dir].WriteDirWith(filepath.Join(root, filepath.FromSlash(dir)), opts)
+		results = append(results, written...)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,90 @@
+package gg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModule_WriteDir(t *testing.T) {
+	mod := NewModule("github.com/example/svc").
+		SetGoVersion("1.21").
+		Require("github.com/google/uuid", "v1.6.0").
+		Require("golang.org/x/sync", "v0.7.0").
+		Require("github.com/google/uuid", "v1.6.1")
+
+	models := mod.Package("models")
+	models.File("user.go").Body().NewStruct("User").
+		AddField("ID", models.File("user.go").P("github.com/google/uuid").Type("UUID"))
+
+	handler := mod.Package("internal/user-handler").File("handler.go")
+	handler.Body().NewFunction("Get").
+		AddResult("", models.Ref(handler).Ptr("User")).
+		AddBody(Return("nil"))
+
+	server := mod.PackageNamed("cmd/server", "main").File("main.go")
+	server.Body().NewFunction("main")
+
+	root := mod.Package(".")
+	root.File("doc.go")
+
+	if mod.Package("./models") != models {
+		t.Errorf("Expected the same package for the same dir")
+	}
+	if got := mod.Package("internal/user-handler").Name(); got != "user_handler" {
+		t.Errorf("Expected package name user_handler, got %s", got)
+	}
+	if root.ImportPath() != "github.com/example/svc" {
+		t.Errorf("Unexpected root import path %s", root.ImportPath())
+	}
+
+	dir := t.TempDir()
+	if err := mod.WriteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `module github.com/example/svc
+
+go 1.21
+
+require (
+	github.com/google/uuid v1.6.1
+	golang.org/x/sync v0.7.0
+)
+`
+	if string(goMod) != expected {
+		t.Errorf("Unexpected go.mod:\n%s", goMod)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "internal", "user-handler", "handler.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareAST(t, `package user_handler
+
+import "github.com/example/svc/models"
+
+func Get() (*models.User) {
+	return nil
+}
+`, string(data))
+
+	for _, name := range []string{"models/user.go", "cmd/server/main.go", "doc.go"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("Expected file %s: %v", name, err)
+		}
+	}
+}
+
+func TestModule_PackageOutside(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic for a dir outside of the module")
+		}
+	}()
+	NewModule("github.com/example/svc").Package("../other")
+}
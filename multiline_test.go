@@ -138,3 +138,78 @@ func TestSlice_SingleLine_Default(t *testing.T) {
 	expected := "[]int{1, 2, 3}"
 	compareAST(t, expected, output)
 }
+
+func TestCall_MultiLine(t *testing.T) {
+	c := Call("fmt.Printf").AddParameter(Lit("%s %d"), "name", "age").MultiLine()
+
+	expected := "fmt.Printf(\n\"%s %d\",\nname,\nage,\n)"
+	if output := renderString(c); output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
+
+func TestFunction_MultiLine(t *testing.T) {
+	fn := Function("New").
+		AddParameter("ctx", "context.Context").
+		AddParameter("a", "int").
+		AddParameter("b", "int").
+		AddResult("", "error").
+		MultiLine()
+
+	expected := "func New(\nctx context.Context,\na, b int,\n)(error)"
+	if output := renderString(fn); output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
+
+func TestGenerator_SetLineWidth(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.SetLineWidth(60)
+	db := gen.P("database/sql")
+	gen.Body().NewFunction("Query").
+		AddParameter("ctx", gen.P("context").Type("Context")).
+		AddParameter("db", db.Ptr("DB")).
+		AddParameter("orgID", "int64").
+		AddResult("", db.Ptr("Rows")).
+		AddResult("", "error").
+		AddBody(
+			Return(Call("db.QueryContext").AddParameter(
+				"ctx",
+				Lit("SELECT id, name FROM users WHERE org_id = ?"),
+				"orgID",
+			)),
+			Return(Call("short").AddParameter("a", "b")),
+		)
+
+	output := gen.String()
+	compareAST(t, `package main
+
+import (
+	"context"
+	"database/sql"
+)
+
+func Query(
+	ctx context.Context,
+	db *sql.DB,
+	orgID int64,
+) (*sql.Rows, error) {
+	return db.QueryContext(
+		ctx,
+		"SELECT id, name FROM users WHERE org_id = ?",
+		orgID,
+	)
+	return short(a, b)
+}
+`, output)
+	if !strings.Contains(output, "return short(a,b)") {
+		t.Errorf("Expected the short call in one line, got:\n%s", output)
+	}
+
+	// Without line width everything stays in one line.
+	gen.SetLineWidth(0)
+	if strings.Count(gen.String(), "\n") != strings.Count(output, "\n")-8 {
+		t.Errorf("Expected the lists in one line, got:\n%s", gen.String())
+	}
+}
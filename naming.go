@@ -0,0 +1,342 @@
+package gg
+
+import (
+	"go/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultInitialisms are the initialisms of golint, which are written in
+// one case in Go names, like `ID` in `UserID`.
+var defaultInitialisms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP",
+	"HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA",
+	"SMTP", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "UUID",
+	"URI", "URL", "UTF8", "VM", "XML", "XMPP", "XSRF", "XSS",
+}
+
+var defaultNamer = NewNamer()
+
+// Namer converts names between cases with a list of initialisms, the
+// package level functions like PascalCase use the default list.
+//
+// Example:
+//
+//	namer := gg.NewNamer().AddInitialisms("OAuth", "SKU")
+//	namer.PascalCase("oauth_token") // => OAuthToken
+//	namer.CamelCase("SKU list")     // => skuList
+type Namer struct {
+	// initialisms maps the upper case initialisms to their spelling.
+	initialisms map[string]string
+}
+
+// NewNamer creates a namer with the initialisms of golint, like ID, URL and
+// HTTP.
+func NewNamer() *Namer {
+	n := &Namer{initialisms: make(map[string]string)}
+	return n.AddInitialisms(defaultInitialisms...)
+}
+
+// AddInitialisms adds initialisms, which are written as they are given in
+// exported names, like `OAuth`, and in lower case at the start of
+// unexported names.
+func (n *Namer) AddInitialisms(words ...string) *Namer {
+	for _, w := range words {
+		n.initialisms[strings.ToUpper(w)] = w
+	}
+	return n
+}
+
+// RemoveInitialisms removes initialisms, so they are written like other
+// words, like `Id`.
+func (n *Namer) RemoveInitialisms(words ...string) *Namer {
+	for _, w := range words {
+		delete(n.initialisms, strings.ToUpper(w))
+	}
+	return n
+}
+
+// PascalCase converts a name to an exported identifier, like `UserID` for
+// `user_id`, see Identifier. An upper case word of a mixed case name is
+// kept, so an exported Go name is not changed, like `NewABCClient`.
+func (n *Namer) PascalCase(s string) string {
+	mixed := strings.ContainsFunc(s, unicode.IsLower)
+	var b strings.Builder
+	for _, w := range n.SplitWords(s) {
+		b.WriteString(n.title(w, mixed))
+	}
+	return Identifier(b.String())
+}
+
+// CamelCase converts a name to an unexported identifier, like `userID` for
+// `user_id` and `httpServer` for `HTTPServer`, see Identifier.
+func (n *Namer) CamelCase(s string) string {
+	mixed := strings.ContainsFunc(s, unicode.IsLower)
+	var b strings.Builder
+	for i, w := range n.SplitWords(s) {
+		if i == 0 {
+			b.WriteString(strings.ToLower(w))
+		} else {
+			b.WriteString(n.title(w, mixed))
+		}
+	}
+	return Identifier(b.String())
+}
+
+// SnakeCase converts a name to lower case words joined by underscores, like
+// `http_server` for `HTTPServer`.
+func (n *Namer) SnakeCase(s string) string {
+	return strings.ToLower(strings.Join(n.SplitWords(s), "_"))
+}
+
+// KebabCase converts a name to lower case words joined by hyphens, like
+// `http-server` for `HTTPServer`.
+func (n *Namer) KebabCase(s string) string {
+	return strings.ToLower(strings.Join(n.SplitWords(s), "-"))
+}
+
+// Exported returns the exported form of an identifier, only the first word
+// is changed, like `URLPath` for `urlPath` and `User_id` for `user_id`.
+// Leading underscores are removed, like `Foo` for `_foo`. Letters without
+// case, like in `名字`, and digits, like in `_2fa`, cannot be exported and
+// are kept, the result is a valid identifier, see Identifier.
+func (n *Namer) Exported(name string) string {
+	name = strings.TrimLeft(name, "_")
+	start, end, ok := n.firstWord(name)
+	if !ok {
+		return Identifier(name)
+	}
+	return Identifier(name[:start] + n.title(name[start:end], true) + name[end:])
+}
+
+// Unexported returns the unexported form of an identifier, only the first
+// word is changed, like `urlPath` for `URLPath`. A keyword is escaped, like
+// `type_` for `Type`, see Identifier.
+func (n *Namer) Unexported(name string) string {
+	start, end, ok := n.firstWord(name)
+	if !ok {
+		return Identifier(name)
+	}
+	word := name[start:end]
+	if _, ok := n.initialisms[strings.ToUpper(word)]; ok || strings.ToUpper(word) == word {
+		word = strings.ToLower(word)
+	} else {
+		r, size := utf8.DecodeRuneInString(word)
+		word = string(unicode.ToLower(r)) + word[size:]
+	}
+	return Identifier(name[:start] + word + name[end:])
+}
+
+// SplitWords splits a name into words, see the package function
+// SplitWords. An upper case run made of initialisms is split at them, like
+// `HTTPS` and `URL` in `HTTPSURL`.
+func (n *Namer) SplitWords(s string) []string {
+	var words []string
+	for s != "" {
+		start, end, ok := n.firstWord(s)
+		if !ok {
+			break
+		}
+		words = append(words, s[start:end])
+		s = s[end:]
+	}
+	return words
+}
+
+// firstWord returns the byte range of the first word of s, like the package
+// function firstWord, but an upper case run ends after its first initialism.
+func (n *Namer) firstWord(s string) (start, end int, ok bool) {
+	start, end, ok = firstWord(s)
+	if ok {
+		if parts := n.initialismRun(s[start:end]); len(parts) > 1 {
+			end = start + len(parts[0])
+		}
+	}
+	return start, end, ok
+}
+
+// initialismRun splits an upper case word into initialisms, the longest
+// one first, like `HTTPS` and `URL` for `HTTPSURL`. The last one could be a
+// plural, like `URLs`. It returns nil if the word is not made of
+// initialisms only, like `IDENTITY`, which is not `ID` and `ENTITY`.
+func (n *Namer) initialismRun(word string) []string {
+	base := strings.TrimSuffix(word, "s")
+	if base == "" || strings.ContainsFunc(base, unicode.IsLower) {
+		return nil
+	}
+	var parts []string
+	for i := 0; i < len(base); {
+		j := len(base)
+		for ; j > i; j-- {
+			if _, ok := n.initialisms[base[i:j]]; ok {
+				break
+			}
+		}
+		if j == i {
+			return nil
+		}
+		parts = append(parts, base[i:j])
+		i = j
+	}
+	// The `s` of a plural belongs to the last initialism.
+	parts[len(parts)-1] += word[len(base):]
+	return parts
+}
+
+// title writes a word with the first letter in upper case, or as an
+// initialism, a plural initialism keeps the `s`, like `IDs`. An upper case
+// word is kept if keepUpper is true, like `ABC`.
+func (n *Namer) title(word string, keepUpper bool) string {
+	upper := strings.ToUpper(word)
+	if s, ok := n.initialisms[upper]; ok {
+		return s
+	}
+	if base, ok := strings.CutSuffix(upper, "S"); ok {
+		if s, ok := n.initialisms[base]; ok {
+			return s + "s"
+		}
+	}
+	if keepUpper && !strings.ContainsFunc(word, unicode.IsLower) {
+		return word
+	}
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + strings.ToLower(word[size:])
+}
+
+// PascalCase converts a name to an exported identifier with the default
+// initialisms, see Namer.PascalCase.
+//
+// Example:
+//
+//	gg.PascalCase("user_id")     // => UserID
+//	gg.PascalCase("api-version") // => APIVersion
+func PascalCase(s string) string {
+	return defaultNamer.PascalCase(s)
+}
+
+// CamelCase converts a name to an unexported identifier with the default
+// initialisms, see Namer.CamelCase.
+//
+// Example:
+//
+//	gg.CamelCase("UserID")  // => userID
+//	gg.CamelCase("URLPath") // => urlPath
+func CamelCase(s string) string {
+	return defaultNamer.CamelCase(s)
+}
+
+// SnakeCase converts a name to lower case words joined by underscores, see
+// Namer.SnakeCase.
+//
+// Example:
+//
+//	gg.SnakeCase("UserID") // => user_id
+func SnakeCase(s string) string {
+	return defaultNamer.SnakeCase(s)
+}
+
+// KebabCase converts a name to lower case words joined by hyphens, see
+// Namer.KebabCase.
+func KebabCase(s string) string {
+	return defaultNamer.KebabCase(s)
+}
+
+// Exported returns the exported form of an identifier with the default
+// initialisms, see Namer.Exported.
+func Exported(name string) string {
+	return defaultNamer.Exported(name)
+}
+
+// Unexported returns the unexported form of an identifier with the default
+// initialisms, see Namer.Unexported.
+func Unexported(name string) string {
+	return defaultNamer.Unexported(name)
+}
+
+// SplitWords splits a name into words at separators, like `_`, `-` and
+// spaces, and at case changes, an upper case run is one word, like
+// `HTTP` and `Server` in `HTTPServer`, unless it is made of the default
+// initialisms, like `HTTPS` and `URL` in `HTTPSURL`. Digits belong to the
+// word before them, like `Base64` and `Encode` in `Base64Encode`.
+func SplitWords(s string) []string {
+	return defaultNamer.SplitWords(s)
+}
+
+// firstWord returns the byte range of the first word of s, see SplitWords.
+func firstWord(s string) (start, end int, ok bool) {
+	start = strings.IndexFunc(s, isWordRune)
+	if start < 0 {
+		return 0, 0, false
+	}
+	var prev rune
+	for i, r := range s[start:] {
+		i += start
+		if !isWordRune(r) {
+			return start, i, true
+		}
+		if i > start && unicode.IsUpper(r) {
+			if !unicode.IsUpper(prev) {
+				// Like `userID` and `base64Encode`.
+				return start, i, true
+			}
+			// The last upper case letter of a run starts the next word if
+			// a lower case letter follows, like `S` in `HTTPServer`, but
+			// not for a plural, like `IDs`.
+			rest := s[i+utf8.RuneLen(r):]
+			next, size := utf8.DecodeRuneInString(rest)
+			after, _ := utf8.DecodeRuneInString(rest[size:])
+			if unicode.IsLower(next) && (next != 's' || unicode.IsLower(after)) {
+				return start, i, true
+			}
+		}
+		prev = r
+	}
+	return start, len(s), true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Identifier sanitizes an arbitrary string into a valid identifier. Unicode
+// letters, digits and underscores are kept, other characters are replaced
+// by one underscore for each run of them. A leading digit is prefixed with
+// an underscore, and a keyword is escaped, see EscapeKeyword.
+//
+// Example:
+//
+//	gg.Identifier("content-type") // => content_type
+//	gg.Identifier("2fa")          // => _2fa
+//	gg.Identifier("名字")          // => 名字
+func Identifier(s string) string {
+	var b strings.Builder
+	replaced := false
+	for _, r := range s {
+		if isWordRune(r) || r == '_' {
+			b.WriteRune(r)
+			replaced = false
+		} else if !replaced {
+			b.WriteByte('_')
+			replaced = true
+		}
+	}
+	id := b.String()
+	if id == "" {
+		return "_"
+	}
+	if r, _ := utf8.DecodeRuneInString(id); unicode.IsDigit(r) {
+		id = "_" + id
+	}
+	return EscapeKeyword(id)
+}
+
+// EscapeKeyword appends an underscore to a Go keyword, so it could be used
+// as an identifier, like `type_` for `type`. Other names are returned as
+// they are.
+func EscapeKeyword(name string) string {
+	if token.IsKeyword(name) {
+		return name + "_"
+	}
+	return name
+}
@@ -0,0 +1,138 @@
+package gg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"user_id", []string{"user", "id"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"userIDs", []string{"user", "IDs"}},
+		{"Base64Encode", []string{"Base64", "Encode"}},
+		{"UTF8String", []string{"UTF8", "String"}},
+		{"  api-version.v2 ", []string{"api", "version", "v2"}},
+		{"名字_value", []string{"名字", "value"}},
+		{"__", nil},
+		{"GetHTTPSURL", []string{"Get", "HTTPS", "URL"}},
+		{"XMLHTTPRequest", []string{"XML", "HTTP", "Request"}},
+		{"HTTPSURLs", []string{"HTTPS", "URLs"}},
+		{"USER_IDENTITY", []string{"USER", "IDENTITY"}},
+	}
+	for _, tt := range tests {
+		if got := SplitWords(tt.input); !slices.Equal(got, tt.expected) {
+			t.Errorf("SplitWords(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCases(t *testing.T) {
+	tests := []struct {
+		input                       string
+		pascal, camel, snake, kebab string
+	}{
+		{"user_id", "UserID", "userID", "user_id", "user-id"},
+		{"HTTPServer", "HTTPServer", "httpServer", "http_server", "http-server"},
+		{"api-urls", "APIURLs", "apiURLs", "api_urls", "api-urls"},
+		{"UserName", "UserName", "userName", "user_name", "user-name"},
+		{"type", "Type", "type_", "type", "type"},
+		{"2fa code", "_2faCode", "_2faCode", "2fa_code", "2fa-code"},
+		{"GetHTTPSURL", "GetHTTPSURL", "getHTTPSURL", "get_https_url", "get-https-url"},
+		{"iOS", "IOS", "iOS", "i_os", "i-os"},
+		{"API_KEY", "APIKey", "apiKey", "api_key", "api-key"},
+		{"USER_IDENTITY", "UserIdentity", "userIdentity", "user_identity", "user-identity"},
+	}
+	for _, tt := range tests {
+		if got := PascalCase(tt.input); got != tt.pascal {
+			t.Errorf("PascalCase(%q) = %q, want %q", tt.input, got, tt.pascal)
+		}
+		if got := CamelCase(tt.input); got != tt.camel {
+			t.Errorf("CamelCase(%q) = %q, want %q", tt.input, got, tt.camel)
+		}
+		if got := SnakeCase(tt.input); got != tt.snake {
+			t.Errorf("SnakeCase(%q) = %q, want %q", tt.input, got, tt.snake)
+		}
+		if got := KebabCase(tt.input); got != tt.kebab {
+			t.Errorf("KebabCase(%q) = %q, want %q", tt.input, got, tt.kebab)
+		}
+	}
+}
+
+func TestExported(t *testing.T) {
+	tests := []struct {
+		input, exported, unexported string
+	}{
+		{"urlPath", "URLPath", "urlPath"},
+		{"URLPath", "URLPath", "urlPath"},
+		{"userID", "UserID", "userID"},
+		{"ID", "ID", "id"},
+		{"user_id", "User_id", "user_id"},
+		{"Type", "Type", "type_"},
+		{"_foo", "Foo", "_foo"},
+		{"2fa_code", "_2fa_code", "_2fa_code"},
+		{"iOS", "IOS", "iOS"},
+		{"USER", "USER", "user"},
+	}
+	for _, tt := range tests {
+		if got := Exported(tt.input); got != tt.exported {
+			t.Errorf("Exported(%q) = %q, want %q", tt.input, got, tt.exported)
+		}
+		if got := Unexported(tt.input); got != tt.unexported {
+			t.Errorf("Unexported(%q) = %q, want %q", tt.input, got, tt.unexported)
+		}
+	}
+}
+
+func TestPascalCase_GoNames(t *testing.T) {
+	// Exported Go names are kept as they are.
+	for _, name := range []string{"GetHTTPSURL", "XMLHTTPRequest", "ServeHTTP", "UserIDs", "NewABCClient", "UTF8String"} {
+		if got := PascalCase(name); got != name {
+			t.Errorf("PascalCase(%q) = %q, want it unchanged", name, got)
+		}
+	}
+}
+
+func TestNamer_Initialisms(t *testing.T) {
+	namer := NewNamer().AddInitialisms("OAuth", "SKU").RemoveInitialisms("ID")
+	if got := namer.PascalCase("oauth_token"); got != "OAuthToken" {
+		t.Errorf("Expected OAuthToken, got %s", got)
+	}
+	if got := namer.CamelCase("SKU list"); got != "skuList" {
+		t.Errorf("Expected skuList, got %s", got)
+	}
+	if got := namer.PascalCase("user_id"); got != "UserId" {
+		t.Errorf("Expected UserId, got %s", got)
+	}
+	if got := PascalCase("user_id"); got != "UserID" {
+		t.Errorf("Expected the default namer to be unchanged, got %s", got)
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"content-type", "content_type"},
+		{"a -> b", "a_b"},
+		{"2fa", "_2fa"},
+		{"名字", "名字"},
+		{"func", "func_"},
+		{"", "_"},
+		{"!!", "_"},
+	}
+	for _, tt := range tests {
+		if got := Identifier(tt.input); got != tt.expected {
+			t.Errorf("Identifier(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+	if got := EscapeKeyword("range"); got != "range_" {
+		t.Errorf("Expected range_, got %s", got)
+	}
+	if got := EscapeKeyword("string"); got != "string" {
+		t.Errorf("Expected string, got %s", got)
+	}
+}
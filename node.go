@@ -24,13 +24,16 @@ func parseNodes(in []interface{}) []Node {
 }
 
 // parseNode will parse a valid input into a node.
-// For now, we only support two types:
+// For now, we only support three types:
 // - Native Node
+// - CustomNode
 // - golang string
 func parseNode(in interface{}) Node {
 	switch v := in.(type) {
 	case Node:
 		return v
+	case CustomNode:
+		return &icustom{node: v}
 	case string:
 		return String(v)
 	default:
@@ -257,34 +257,11 @@ func resolvePackageAlias(importPath string, existingAliases map[string]bool) str
 	return alias
 }
 
-// sanitizeIdentifier ensures the string is a valid Go identifier
+// sanitizeIdentifier ensures the string is a valid Go identifier, see
+// Identifier.
 func sanitizeIdentifier(s string) string {
 	if s == "" {
 		return "pkg"
 	}
-
-	var result strings.Builder
-	for i, r := range s {
-		if i == 0 {
-			// First character must be letter or underscore
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' {
-				result.WriteRune(r)
-			} else {
-				result.WriteRune('_')
-			}
-		} else {
-			// Subsequent characters can be letters, digits, or underscore
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
-				result.WriteRune(r)
-			} else if r == '-' || r == '.' {
-				result.WriteRune('_')
-			}
-			// Skip other invalid characters
-		}
-	}
-
-	if result.Len() == 0 {
-		return "pkg"
-	}
-	return result.String()
+	return Identifier(s)
 }
@@ -207,7 +207,7 @@ func TestResolvePackageAlias(t *testing.T) {
 		{"github.com/example/types", map[string]bool{"types": true}, "types2"},
 		{"github.com/example/types", map[string]bool{"types": true, "types2": true}, "types3"},
 		{"github.com/example/some-pkg", nil, "some_pkg"},
-		{"github.com/example/123pkg", nil, "_23pkg"},
+		{"github.com/example/123pkg", nil, "_123pkg"},
 	}
 
 	for _, tt := range tests {
@@ -228,10 +228,12 @@ func TestSanitizeIdentifier(t *testing.T) {
 	}{
 		{"types", "types"},
 		{"some-pkg", "some_pkg"},
-		{"123pkg", "_23pkg"},
+		{"123pkg", "_123pkg"},
 		{"my.pkg", "my_pkg"},
 		{"", "pkg"},
 		{"ValidName", "ValidName"},
+		{"café", "café"},
+		{"type", "type_"},
 	}
 
 	for _, tt := range tests {
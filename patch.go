@@ -0,0 +1,427 @@
+package gg
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PatchFile adds the generated code into an existing Go file. The file is
+// parsed, and:
+//
+//   - imports of the generator are merged into the import block of the file,
+//     packages imported already keep their name in the file, and new aliases
+//     are resolved against the names of the file;
+//   - declarations declared by the file too, like a function or a method
+//     with the same name, are replaced in place with their doc comments;
+//   - other nodes are appended at the end of the file;
+//   - imports which were only used by the replaced declarations are removed.
+//
+// The file is written like WriteFileWith. It returns an error if the file
+// could not be parsed, is in another package, or a replaced declaration
+// declares other names too, like `var a, b = 1, 2` replaced by `var a = 1`.
+//
+// Example:
+//
+//	gen := gg.New()
+//	gen.Body().NewFunction("ErrNotFound").
+//		AddResult("", "error").
+//		AddBody(gg.Return(gen.P("errors").Call("New", gg.Lit("not found"))))
+//	res, err := gen.PatchFile("errors.go", gg.WriteOptions{})
+func (g *Generator) PatchFile(path string, opts WriteOptions) (WriteResult, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return WriteResult{Path: path}, fmt.Errorf("read file %s: %s", path, err)
+	}
+	out, err := g.patch(path, src)
+	if err != nil {
+		return WriteResult{Path: path}, fmt.Errorf("patch file %s: %s", path, err)
+	}
+	return writeFile(path, out, opts)
+}
+
+// textEdit replaces src[start:end] with text.
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+func (g *Generator) patch(path string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	offset := func(pos token.Pos) int {
+		return fset.Position(pos).Offset
+	}
+
+	// A generator with the imports of the file, the merged copy of g refers
+	// to the packages by their names in the file.
+	f := New().SetPackage(file.Name.Name)
+	imported := fileImports(file)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := resolvePackageAlias(importPath, nil)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		if existing, ok := f.aliasToPath[name]; ok && existing != importPath {
+			continue
+		}
+		f.PAlias(importPath, name)
+	}
+	if name := g.declaredPackage(); name != "" && name != file.Name.Name {
+		return nil, fmt.Errorf("cannot patch package %s with package %s", file.Name.Name, name)
+	}
+	f.importPath = g.importPath
+	if err := f.Merge(g); err != nil {
+		return nil, err
+	}
+
+	// Declarations of the file by name.
+	declared := make(map[string]ast.Decl)
+	for _, d := range file.Decls {
+		for _, name := range astDeclNames(d) {
+			declared[name] = d
+		}
+	}
+
+	var edits []textEdit
+	type replacement struct {
+		decl  ast.Decl
+		items []Node
+		node  Node
+	}
+	var replaced []replacement
+	eachDecl(f.g, func(parent *Group, idx int, n Node) bool {
+		names := declNames(n)
+		if len(names) == 0 {
+			return true
+		}
+		old, ok := declared[names[0]]
+		if !ok {
+			return true
+		}
+		replaced = append(replaced, replacement{
+			decl:  old,
+			items: parent.items[leadingComments(parent, idx) : idx+1],
+			node:  n,
+		})
+		return true
+	})
+	for _, r := range replaced {
+		names := declNames(r.node)
+		for _, name := range astDeclNames(r.decl) {
+			if !slices.Contains(names, name) {
+				return nil, fmt.Errorf("cannot replace %s, %s is declared together", names[0], name)
+			}
+		}
+		for _, name := range names {
+			if d, ok := declared[name]; ok && d != r.decl {
+				return nil, fmt.Errorf("cannot replace %s, %s is declared separately", names[0], name)
+			}
+		}
+		start := r.decl.Pos()
+		if doc := declDoc(r.decl); doc != nil {
+			start = doc.Pos()
+		}
+		group := NewGroup()
+		group.items = append(group.items, r.items...)
+		edits = append(edits, textEdit{
+			start: offset(start),
+			end:   offset(r.decl.End()),
+			text:  f.renderNode(group),
+		})
+	}
+	for _, r := range replaced {
+		f.g.Remove(r.node)
+	}
+
+	// The rest of the body is appended.
+	if rest := strings.TrimSpace(f.renderNode(f.g)); rest != "" {
+		text := "\n" + rest + "\n"
+		if len(src) > 0 && src[len(src)-1] != '\n' {
+			text = "\n" + text
+		}
+		edits = append(edits, textEdit{start: len(src), end: len(src), text: text})
+	}
+
+	// Imports are added after rendering, custom nodes could register them.
+	if edit, ok := f.importEdit(file, src, offset, imported); ok {
+		edits = append(edits, edit)
+	}
+
+	out, err := dropUnusedImports(path, file, applyEdits(string(src), edits))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// dropUnusedImports removes the imports which the file referenced before
+// patching but the patched source does not, like an import only used by a
+// replaced declaration. Imports unused by the file already are kept.
+func dropUnusedImports(path string, file *ast.File, src string) (string, error) {
+	fset := token.NewFileSet()
+	patched, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+	offset := func(pos token.Pos) int {
+		return fset.Position(pos).Offset
+	}
+	before, after := usedPackageNames(file), usedPackageNames(patched)
+
+	var edits []textEdit
+	for _, d := range patched.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		var unused []*ast.ImportSpec
+		for _, spec := range gd.Specs {
+			spec := spec.(*ast.ImportSpec)
+			if name := importSpecName(spec); before[name] && !after[name] {
+				unused = append(unused, spec)
+			}
+		}
+		if len(unused) > 0 && len(unused) == len(gd.Specs) {
+			start := gd.Pos()
+			if gd.Doc != nil {
+				start = gd.Doc.Pos()
+			}
+			edit := lineEdit(src, offset(start), offset(gd.End()))
+			// The blank line after the import declaration goes with it.
+			if edit.end < len(src) && src[edit.end] == '\n' && edit.start > 1 && src[edit.start-2] == '\n' {
+				edit.end++
+			}
+			edits = append(edits, edit)
+			continue
+		}
+		for _, spec := range unused {
+			end := spec.End()
+			if spec.Comment != nil {
+				end = spec.Comment.End()
+			}
+			edits = append(edits, lineEdit(src, offset(spec.Pos()), offset(end)))
+		}
+	}
+	return applyEdits(src, edits), nil
+}
+
+// lineEdit returns the edit removing src[start:end], with the whole line if
+// nothing else is on it.
+func lineEdit(src string, start, end int) textEdit {
+	s := start
+	for s > 0 && (src[s-1] == ' ' || src[s-1] == '\t') {
+		s--
+	}
+	e := end
+	for e < len(src) && (src[e] == ' ' || src[e] == '\t') {
+		e++
+	}
+	if (s == 0 || src[s-1] == '\n') && (e == len(src) || src[e] == '\n') {
+		if e < len(src) {
+			e++
+		}
+		return textEdit{start: s, end: e}
+	}
+	return textEdit{start: start, end: end}
+}
+
+// usedPackageNames returns the names used as the operand of a selector
+// outside the imports, like `errors` in `errors.New`.
+func usedPackageNames(file *ast.File) map[string]bool {
+	used := make(map[string]bool)
+	for _, d := range file.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			continue
+		}
+		ast.Inspect(d, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if id, ok := sel.X.(*ast.Ident); ok {
+					used[id.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	return used
+}
+
+// importSpecName returns the name of an import spec in the file, like
+// `errs` for `errs "github.com/pkg/errors"`.
+func importSpecName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	importPath, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+	return resolvePackageAlias(importPath, nil)
+}
+
+// applyEdits applies non-overlapping edits to the source.
+func applyEdits(src string, edits []textEdit) string {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, e := range edits {
+		src = src[:e.start] + e.text + src[e.end:]
+	}
+	return src
+}
+
+// fileImports returns the import specs of a parsed file, see importKey.
+func fileImports(file *ast.File) map[[2]string]bool {
+	imported := make(map[[2]string]bool, len(file.Imports))
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := resolvePackageAlias(importPath, nil)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imported[importKey(importPath, name)] = true
+	}
+	return imported
+}
+
+// renderNode renders a node with the generator.
+func (g *Generator) renderNode(n Node) string {
+	buf := pool.Get()
+	defer buf.Free()
+	n.render(&RenderContext{w: buf, gen: g})
+	return buf.String()
+}
+
+// importEdit returns the edit adding the imports of g which are missing in
+// the file.
+func (g *Generator) importEdit(file *ast.File, src []byte, offset func(token.Pos) int, imported map[[2]string]bool) (textEdit, bool) {
+	stdLib, thirdParty := g.importGroups()
+	var specs []string
+	for _, p := range append(stdLib, thirdParty...) {
+		for _, name := range g.importNames(p) {
+			if imported[importKey(p, name)] {
+				continue
+			}
+			if name == "" {
+				specs = append(specs, strconv.Quote(p))
+			} else {
+				specs = append(specs, name+" "+strconv.Quote(p))
+			}
+		}
+	}
+	if len(specs) == 0 {
+		return textEdit{}, false
+	}
+
+	var last *ast.GenDecl
+	for _, d := range file.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			last = gd
+		}
+	}
+	switch {
+	case last == nil:
+		// After the line of the package clause.
+		at := offset(file.Name.End())
+		if i := strings.IndexByte(string(src[at:]), '\n'); i >= 0 {
+			at += i
+		} else {
+			at = len(src)
+		}
+		return textEdit{start: at, end: at, text: "\n\nimport (\n\t" + strings.Join(specs, "\n\t") + "\n)"}, true
+	case last.Lparen.IsValid():
+		at := offset(last.Rparen)
+		text := "\t" + strings.Join(specs, "\n\t") + "\n"
+		if at > 0 && src[at-1] != '\n' {
+			text = "\n" + text
+		}
+		return textEdit{start: at, end: at, text: text}, true
+	default:
+		existing := string(src[offset(last.Specs[0].Pos()):offset(last.Specs[0].End())])
+		return textEdit{
+			start: offset(last.Pos()),
+			end:   offset(last.End()),
+			text:  "import (\n\t" + existing + "\n\t" + strings.Join(specs, "\n\t") + "\n)",
+		}, true
+	}
+}
+
+// importKey identifies an import spec, the name is empty while it is the
+// default name of the package.
+func importKey(importPath, name string) [2]string {
+	if name == resolvePackageAlias(importPath, nil) {
+		name = ""
+	}
+	return [2]string{importPath, name}
+}
+
+// astDeclNames returns the names declared by a declaration of a parsed
+// file, named like declNames.
+func astDeclNames(d ast.Decl) []string {
+	var names []string
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if d.Recv == nil {
+			if d.Name.Name != "init" {
+				names = append(names, d.Name.Name)
+			}
+			break
+		}
+		if len(d.Recv.List) > 0 {
+			if recv := astReceiverType(d.Recv.List[0].Type); recv != "" {
+				names = append(names, recv+"."+d.Name.Name)
+			}
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					if n.Name != "_" {
+						names = append(names, n.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// astReceiverType returns the type name of a receiver, without pointer and
+// type parameters.
+func astReceiverType(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return astReceiverType(e.X)
+	case *ast.ParenExpr:
+		return astReceiverType(e.X)
+	case *ast.IndexExpr:
+		return astReceiverType(e.X)
+	case *ast.IndexListExpr:
+		return astReceiverType(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
@@ -0,0 +1,240 @@
+package gg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const patchSource = `package store
+
+import (
+	"context"
+	errs "github.com/pkg/errors"
+)
+
+// Get returns the user.
+func Get(ctx context.Context, id int) (*User, error) {
+	return nil, errs.New("todo")
+}
+
+func (s *Store) Close() error {
+	return nil
+}
+`
+
+func writePatchSource(t *testing.T, src string) string {
+	path := filepath.Join(t.TempDir(), "store.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGenerator_PatchFile(t *testing.T) {
+	path := writePatchSource(t, patchSource)
+
+	gen := New()
+	gen.SetPackage("store")
+	ctx := gen.P("context")
+	errors := gen.P("github.com/pkg/errors")
+	other := gen.P("github.com/example/errs")
+
+	gen.Body().AddLineComment("Get returns the user by id.")
+	gen.Body().NewFunction("Get").
+		AddParameter("ctx", ctx.Type("Context")).
+		AddParameter("id", "int").
+		AddResult("", "*User").
+		AddResult("", "error").
+		AddBody(Return("nil", errors.Call("New", Lit("not found"))))
+	gen.Body().NewFunction("Wrap").
+		AddParameter("err", "error").
+		AddResult("", "error").
+		AddBody(Return(other.Call("Wrap", "err")))
+	gen.Body().NewFunction("Close").
+		WithReceiver("s", "*Store").
+		AddResult("", "error").
+		AddBody(Return(gen.P("fmt").Call("Errorf", Lit("closed"))))
+
+	res, err := gen.PatchFile(path, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Changed {
+		t.Errorf("Expected the file to be changed")
+	}
+	data, _ := os.ReadFile(path)
+	output := string(data)
+
+	compareAST(t, `package store
+
+import (
+	"context"
+	errs "github.com/pkg/errors"
+	"fmt"
+	errs2 "github.com/example/errs"
+)
+
+// Get returns the user by id.
+func Get(ctx context.Context, id int) (*User, error) {
+	return nil, errs.New("not found")
+}
+
+func (s *Store) Close() (error) {
+	return fmt.Errorf("closed")
+}
+
+func Wrap(err error) (error) {
+	return errs2.Wrap(err)
+}
+`, output)
+	if strings.Contains(output, "Get returns the user.") {
+		t.Errorf("Expected the doc comment to be replaced, got:\n%s", output)
+	}
+}
+
+func TestGenerator_PatchFile_Imports(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "no import",
+			src:      "package store // store\n\nvar x = 1\n",
+			expected: "package store // store\n\nimport (\n\t\"errors\"\n)\n\nvar x = 1\n",
+		},
+		{
+			name:     "single import",
+			src:      "package store\n\nimport \"context\"\n\nvar x = 1\n",
+			expected: "package store\n\nimport (\n\t\"context\"\n\t\"errors\"\n)\n\nvar x = 1\n",
+		},
+		{
+			name:     "imported",
+			src:      "package store\n\nimport (\n\t\"errors\"\n)\n\nvar x = 1\n",
+			expected: "package store\n\nimport (\n\t\"errors\"\n)\n\nvar x = 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writePatchSource(t, tt.src)
+			gen := New()
+			gen.P("errors")
+			if _, err := gen.PatchFile(path, WriteOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.expected {
+				t.Errorf("Unexpected output:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestGenerator_PatchFile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		gen  func() *Generator
+		err  string
+	}{
+		{
+			name: "parse error",
+			src:  "package store\n\nfunc {\n",
+			gen:  New,
+			err:  "expected",
+		},
+		{
+			name: "another package",
+			src:  "package store\n",
+			gen:  func() *Generator { return New().SetPackage("models") },
+			err:  "cannot patch package store with package models",
+		},
+		{
+			name: "declared together",
+			src:  "package store\n\nvar a, b = 1, 2\n",
+			gen: func() *Generator {
+				gen := New()
+				gen.Body().NewVar().AddField("a", Lit(3))
+				return gen
+			},
+			err: "cannot replace a, b is declared together",
+		},
+		{
+			name: "declared separately",
+			src:  "package store\n\nvar a = 1\n\nvar b = 2\n",
+			gen: func() *Generator {
+				gen := New()
+				gen.Body().NewVar().AddField("a", Lit(3)).AddField("b", Lit(4))
+				return gen
+			},
+			err: "cannot replace a, b is declared separately",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writePatchSource(t, tt.src)
+			_, err := tt.gen().PatchFile(path, WriteOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Expected error %q, got %v", tt.err, err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.src {
+				t.Errorf("Expected the file not to be changed, got:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestGenerator_PatchFile_UnusedImports(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "block",
+			src:      "package store\n\nimport (\n\t\"os\"\n\t\"strconv\" // parse\n)\n\nfunc Parse(s string) int {\n\tn, _ := strconv.Atoi(s)\n\treturn n\n}\n\nvar out = os.Stdout\n",
+			expected: "package store\n\nimport (\n\t\"os\"\n)\n\nfunc Parse",
+		},
+		{
+			name:     "single",
+			src:      "package store\n\nimport \"strconv\"\n\nfunc Parse(s string) int {\n\tn, _ := strconv.Atoi(s)\n\treturn n\n}\n",
+			expected: "package store\n\nfunc Parse",
+		},
+		{
+			name:     "unused before",
+			src:      "package store\n\nimport \"strconv\"\n\nfunc Parse(s string) int {\n\treturn 0\n}\n",
+			expected: "package store\n\nimport \"strconv\"\n\nfunc Parse",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writePatchSource(t, tt.src)
+			gen := New()
+			gen.Body().NewFunction("Parse").
+				AddParameter("s", "string").
+				AddResult("", "int").
+				AddBody(Return("len(s)"))
+			if _, err := gen.PatchFile(path, WriteOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), tt.expected) {
+				t.Errorf("Unexpected output:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestGenerator_AppendFile(t *testing.T) {
+	path := writePatchSource(t, "package store\n\nfunc Get() {}\n")
+	gen := New()
+	gen.SetPackage("store")
+	gen.Body().NewFunction("Get")
+	if err := gen.AppendFile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Count(string(data), "func Get()") != 2 || strings.Count(string(data), "package store") != 1 {
+		t.Errorf("Expected the body to be appended as it is, got:\n%s", data)
+	}
+}
@@ -0,0 +1,210 @@
+package gg
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+const provenancePrefix = "// gg:from "
+
+// Provenance is the call site which added a node, see SetProvenance.
+type Provenance struct {
+	File string
+	Line int
+}
+
+func (p Provenance) String() string {
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
+}
+
+var (
+	provenanceEnabled atomic.Bool
+
+	// packageDir is the directory of this package, its frames are skipped
+	// while looking for the call site.
+	packageDir = func() string {
+		_, file, _, _ := runtime.Caller(0)
+		return filepath.Dir(file)
+	}()
+)
+
+// SetProvenance enables or disables recording the call sites of nodes, for
+// debugging generators. While it is enabled, every node appended to a
+// group, like by NewFunction, AddBody or AddField, records the file and line
+// of the caller, see Generator.SourceMap. The site is recorded when the node
+// is appended, not when it is created, so the node of `gg.Return("x")` in
+// `fn.AddBody(gg.Return("x"))` has the site of AddBody. The sites are kept
+// by the groups, like their items.
+//
+// Example:
+//
+//	gg.SetProvenance(true)
+//	defer gg.SetProvenance(false)
+func SetProvenance(enabled bool) {
+	provenanceEnabled.Store(enabled)
+}
+
+// ProvenanceOf returns the call site which appended the node to the group,
+// it is false if the node is not an item of the group, or was appended while
+// recording is disabled.
+func (g *Group) ProvenanceOf(n Node) (Provenance, bool) {
+	p, ok := g.sites[n]
+	return p, ok
+}
+
+// recordProvenance records the caller for the nodes which have no call site
+// in the group yet.
+func (g *Group) recordProvenance(nodes []Node) {
+	if !provenanceEnabled.Load() {
+		return
+	}
+	site, ok := callSite()
+	if !ok {
+		return
+	}
+	if g.sites == nil {
+		g.sites = make(map[Node]Provenance, len(nodes))
+	}
+	for _, n := range nodes {
+		if _, ok := g.sites[n]; !ok {
+			g.sites[n] = site
+		}
+	}
+}
+
+// callSite returns the first caller out of this package, tests of the
+// package count as callers.
+func callSite() (Provenance, bool) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if frame.File != "" && (filepath.Dir(frame.File) != packageDir || strings.HasSuffix(frame.File, "_test.go")) {
+			return Provenance{File: frame.File, Line: frame.Line}, true
+		}
+		if !more {
+			return Provenance{}, false
+		}
+	}
+}
+
+// SourceMapping maps lines of the output to the node which rendered them.
+type SourceMapping struct {
+	// StartLine and EndLine are the first and last line, starting at 1.
+	StartLine, EndLine int
+	Node               Node
+	Provenance         Provenance
+}
+
+// SourceMap holds the mappings of the output ordered by their first line,
+// outer nodes before the nodes inside them.
+type SourceMap []SourceMapping
+
+// Find returns the innermost mapping of the line.
+func (m SourceMap) Find(line int) (SourceMapping, bool) {
+	var found SourceMapping
+	ok := false
+	for _, s := range m {
+		if s.StartLine <= line && line <= s.EndLine && (!ok || s.EndLine-s.StartLine <= found.EndLine-found.StartLine) {
+			found, ok = s, true
+		}
+	}
+	return found, ok
+}
+
+// SourceMap renders the generator and maps the output lines to the call
+// sites of the nodes which rendered them. Only nodes appended to a group
+// while SetProvenance is enabled are mapped.
+//
+// Example:
+//
+//	gg.SetProvenance(true)
+//	// ... build the code
+//	if m, ok := gen.SourceMap().Find(42); ok {
+//		fmt.Println(m.Provenance) // gen/models.go:87
+//	}
+func (g *Generator) SourceMap() SourceMap {
+	var spans []nodeSpan
+	buf := pool.Get()
+	defer buf.Free()
+	g.renderFile(buf, &spans)
+	src := buf.String()
+	// The stamp adds lines before the code.
+	out := src
+	if g.checksum {
+		out = stampChecksum(src)
+	}
+	return newSourceMap(out, spans, len(out)-len(src))
+}
+
+// SetProvenanceComments enables trailing comments with the call sites of
+// the nodes which rendered each line, like `// gg:from models.go:87`, for
+// debugging. Lines in multi-line raw strings are commented too, so it
+// should not be used for generated code with them.
+func (g *Generator) SetProvenanceComments(enabled bool) *Generator {
+	g.provenanceComments = enabled
+	return g
+}
+
+// newSourceMap converts the output ranges of the rendered nodes into lines,
+// the ranges start at shift in src.
+func newSourceMap(src string, spans []nodeSpan, shift int) SourceMap {
+	var lineStarts []int
+	lineStarts = append(lineStarts, 0)
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	lineOf := func(offset int) int {
+		return sort.SearchInts(lineStarts, offset+1)
+	}
+
+	var m SourceMap
+	for _, s := range spans {
+		if s.end <= s.start {
+			continue
+		}
+		if s.group == nil {
+			continue
+		}
+		site, ok := s.group.ProvenanceOf(s.node)
+		if !ok {
+			continue
+		}
+		m = append(m, SourceMapping{
+			StartLine:  lineOf(s.start + shift),
+			EndLine:    lineOf(s.end - 1 + shift),
+			Node:       s.node,
+			Provenance: site,
+		})
+	}
+	sort.SliceStable(m, func(i, j int) bool {
+		if m[i].StartLine != m[j].StartLine {
+			return m[i].StartLine < m[j].StartLine
+		}
+		return m[i].EndLine > m[j].EndLine
+	})
+	return m
+}
+
+// annotateProvenance appends the call site comments to the lines of src.
+func annotateProvenance(src string, m SourceMap) string {
+	lines := strings.SplitAfter(src, "\n")
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\n")
+		// Markers of the user regions must not be changed.
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(strings.TrimSpace(text), "// gg:") {
+			continue
+		}
+		if s, ok := m.Find(i + 1); ok {
+			site := Provenance{File: filepath.Base(s.Provenance.File), Line: s.Provenance.Line}
+			lines[i] = text + " " + provenancePrefix + site.String() + line[len(text):]
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,84 @@
+package gg
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func line() int {
+	_, _, l, _ := runtime.Caller(1)
+	return l
+}
+
+func TestGenerator_SourceMap(t *testing.T) {
+	SetProvenance(true)
+	defer SetProvenance(false)
+
+	gen := New()
+	gen.SetPackage("main")
+	fnLine := line() + 1
+	fn := gen.Body().NewFunction("main")
+	call := gen.P("fmt").Call("Println", Lit("hello"))
+	callLine := line() + 1
+	fn.AddBody(call)
+
+	if p, ok := gen.Body().ProvenanceOf(fn); !ok || p.Line != fnLine || filepath.Base(p.File) != "provenance_test.go" {
+		t.Errorf("Unexpected provenance of the function: %v", p)
+	}
+
+	// The line of the call, after the package and import lines.
+	m := gen.SourceMap()
+	s, ok := m.Find(6)
+	if !ok || s.Node != call || s.Provenance.Line != callLine {
+		t.Errorf("Unexpected mapping of line 6: %+v", s)
+	}
+	if s, ok := m.Find(5); !ok || s.Node != fn || s.StartLine != 5 || s.EndLine != 6 {
+		t.Errorf("Unexpected mapping of line 5: %+v", s)
+	}
+	if _, ok := m.Find(1); ok {
+		t.Errorf("Expected the package clause not to be mapped")
+	}
+
+	gen.SetChecksum(true).SetProvenanceComments(true)
+	lines := strings.Split(gen.String(), "\n")
+	if !strings.HasSuffix(lines[7], fmt.Sprintf("// gg:from provenance_test.go:%d", callLine)) {
+		t.Errorf("Expected the call to be commented, got %q", lines[7])
+	}
+	if _, ok := gen.SourceMap().Find(8); !ok {
+		t.Errorf("Expected the lines to follow the checksum stamp")
+	}
+	if err := VerifyChecksum([]byte(gen.String())); err != nil {
+		t.Errorf("Expected the commented output to be stamped, got %v", err)
+	}
+
+	if p, ok := gen.Clone().SourceMap().Find(8); !ok || p.Provenance.Line != callLine {
+		t.Errorf("Expected the clone to keep the call sites, got %+v", p)
+	}
+
+	SetProvenance(false)
+	other := gen.Body().NewFunction("other")
+	if _, ok := gen.Body().ProvenanceOf(other); ok {
+		t.Errorf("Expected no provenance while disabled")
+	}
+}
+
+func TestGroup_ProvenanceOf_Any(t *testing.T) {
+	SetProvenance(true)
+	defer SetProvenance(false)
+
+	a, b := Any(), Any()
+	g := NewGroup()
+	aLine := line() + 1
+	g.Append(a)
+	bLine := line() + 1
+	g.Append(b)
+	if p, ok := g.ProvenanceOf(a); !ok || p.Line != aLine {
+		t.Errorf("Unexpected provenance of a: %v", p)
+	}
+	if p, ok := g.ProvenanceOf(b); !ok || p.Line != bLine {
+		t.Errorf("Unexpected provenance of b: %v", p)
+	}
+}
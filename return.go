@@ -15,6 +15,9 @@ func Return(node ...interface{}) *ireturn {
 }
 
 func (i *ireturn) render(w io.Writer) {
-	writeString(w, "return ")
-	i.items.render(w)
+	writeString(w, "return")
+	if i.items.length() > 0 {
+		writeString(w, " ")
+		i.items.render(w)
+	}
 }
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"github.com/Xuanwo/go-bufferpool"
 	"io"
+	"reflect"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode/utf8"
 )
 
 var pool = bufferpool.New(1024)
@@ -78,20 +81,104 @@ func LineComment(content string, args ...interface{}) *istring {
 	return String(content)
 }
 
+// litTyping controls whether numeric literals carry an explicit conversion.
+type litTyping int
+
+const (
+	litTypingDefault litTyping = iota // convert only when the default type differs
+	litTyped                          // always convert, like `int(1)`
+	litUntyped                        // never convert, like `1`
+)
+
 type lit struct {
 	value interface{}
+
+	raw        bool
+	rune       bool
+	bytes      bool
+	base       int
+	separators bool
+	typing     litTyping
 }
 
 func Lit(value interface{}) *lit {
 	return &lit{value: value}
 }
 
+// Raw renders string literals as backtick raw strings, which keeps
+// multi-line text readable.
+//
+// We will fall back to an interpreted string literal while the string can't
+// be represented as a raw string, like containing a backtick or `\r`.
+func (v *lit) Raw() *lit {
+	v.raw = true
+	return v
+}
+
+// Rune renders integer literals as rune literals, like `'a'`.
+func (v *lit) Rune() *lit {
+	v.rune = true
+	return v
+}
+
+// Bytes renders string literals as byte slice conversions, like `[]byte("abc")`.
+func (v *lit) Bytes() *lit {
+	v.bytes = true
+	return v
+}
+
+// Hex renders integer literals in hexadecimal, like `0xff`.
+func (v *lit) Hex() *lit {
+	v.base = 16
+	return v
+}
+
+// Octal renders integer literals in octal, like `0o755`.
+func (v *lit) Octal() *lit {
+	v.base = 8
+	return v
+}
+
+// Binary renders integer literals in binary, like `0b1010`.
+func (v *lit) Binary() *lit {
+	v.base = 2
+	return v
+}
+
+// DigitSeparators inserts `_` between digit groups of integer literals,
+// like `1_000_000` or `0xdead_beef`.
+func (v *lit) DigitSeparators() *lit {
+	v.separators = true
+	return v
+}
+
+// Typed always renders numeric literals with an explicit conversion,
+// like `int(1)` or `float64(1.5)`.
+func (v *lit) Typed() *lit {
+	v.typing = litTyped
+	return v
+}
+
+// Untyped renders numeric literals as untyped constants, like `1` for
+// `int64(1)`. Use it while the literal is assigned to a typed destination.
+func (v *lit) Untyped() *lit {
+	v.typing = litUntyped
+	return v
+}
+
 func (v *lit) render(w io.Writer) {
 	var out string
 
 	// Code borrowed from github.com/dave/jennifer
-	switch v.value.(type) {
-	case bool, string, int, complex128:
+	switch x := v.value.(type) {
+	case string:
+		out = v.formatString(x)
+	case []byte:
+		out = v.formatString(string(x))
+		if !v.bytes {
+			out = "[]byte(" + out + ")"
+		}
+	case bool, complex128:
 		out = fmt.Sprintf("%#v", v.value)
 	case float64:
 		out = fmt.Sprintf("%#v", v.value)
@@ -103,8 +190,13 @@ func (v *lit) render(w io.Writer) {
 		if !strings.Contains(out, ".") && !strings.Contains(out, "e") {
 			out += ".0"
 		}
-	case float32, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
-		out = fmt.Sprintf("%T(%#v)", v.value, v.value)
+		out = v.convert(out, "float64")
+	case float32:
+		out = v.convert(fmt.Sprintf("%#v", v.value), "float64")
+	case int, int8, int16, int32, int64:
+		out = v.formatInt(reflect.ValueOf(x).Int())
+	case uint, uint8, uint16, uint32, uint64, uintptr:
+		out = v.formatUint(reflect.ValueOf(x).Uint())
 	case complex64:
 		out = fmt.Sprintf("%T%#v", v.value, v.value)
 	default:
@@ -114,6 +206,85 @@ func (v *lit) render(w io.Writer) {
 	writeString(w, out)
 }
 
+func (v *lit) formatString(s string) string {
+	var out string
+	if v.raw && canRawQuote(s) {
+		out = "`" + s + "`"
+	} else {
+		out = strconv.Quote(s)
+	}
+	if v.bytes {
+		out = "[]byte(" + out + ")"
+	}
+	return out
+}
+
+// canRawQuote reports whether s can be represented as a raw string literal
+// without changing its value, the compiler rejects NUL in source files.
+func canRawQuote(s string) bool {
+	return utf8.ValidString(s) &&
+		!strings.ContainsAny(s, "`\r\ufeff\x00")
+}
+
+func (v *lit) formatInt(i int64) string {
+	if i < 0 {
+		return v.convertInt("-" + v.digits(uint64(-i)))
+	}
+	return v.formatUint(uint64(i))
+}
+
+func (v *lit) formatUint(u uint64) string {
+	if v.rune && u <= utf8.MaxRune && utf8.ValidRune(rune(u)) {
+		return v.convert(strconv.QuoteRune(rune(u)), "int32")
+	}
+	return v.convertInt(v.digits(u))
+}
+
+// digits renders an unsigned integer in the configured base.
+func (v *lit) digits(u uint64) string {
+	base, prefix, group := 10, "", 3
+	switch v.base {
+	case 16:
+		base, prefix, group = 16, "0x", 4
+	case 8:
+		base, prefix = 8, "0o"
+	case 2:
+		base, prefix, group = 2, "0b", 4
+	}
+
+	ds := strconv.FormatUint(u, base)
+	if v.separators && len(ds) > group {
+		var b strings.Builder
+		for i, c := range ds {
+			if i > 0 && (len(ds)-i)%group == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(c)
+		}
+		ds = b.String()
+	}
+	return prefix + ds
+}
+
+func (v *lit) convertInt(out string) string {
+	return v.convert(out, "int")
+}
+
+// convert wraps out with a conversion to the value's type if needed.
+// defaultType is the type an untyped constant of out would default to.
+func (v *lit) convert(out, defaultType string) string {
+	typ := fmt.Sprintf("%T", v.value)
+	switch v.typing {
+	case litUntyped:
+		return out
+	case litTypingDefault:
+		if typ == defaultType {
+			return out
+		}
+	}
+	return typ + "(" + out + ")"
+}
+
 func (v *lit) String() string {
 	buf := pool.Get()
 	defer buf.Free()
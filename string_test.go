@@ -61,6 +61,48 @@ func TestLit(t *testing.T) {
 	compareAST(t, expected, buf.String())
 }
 
+func TestLitOptions(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  *lit
+		expect string
+	}{
+		{"default string", Lit("a\nb"), `"a\nb"`},
+		{"raw string", Lit("a\nb").Raw(), "`a\nb`"},
+		{"raw fallback backtick", Lit("a`b").Raw(), "\"a`b\""},
+		{"raw fallback carriage return", Lit("a\r\nb").Raw(), `"a\r\nb"`},
+		{"raw fallback nul", Lit("a\x00b").Raw(), `"a\x00b"`},
+		{"bytes", Lit("abc").Bytes(), `[]byte("abc")`},
+		{"raw bytes", Lit("a\"b").Raw().Bytes(), "[]byte(`a\"b`)"},
+		{"byte slice", Lit([]byte("abc")), `[]byte("abc")`},
+		{"byte slice bytes", Lit([]byte("abc")).Bytes(), `[]byte("abc")`},
+		{"default rune", Lit('a'), "int32(97)"},
+		{"rune", Lit('a').Rune(), "'a'"},
+		{"escaped rune", Lit('\n').Rune(), `'\n'`},
+		{"byte rune", Lit(byte('a')).Rune(), "uint8('a')"},
+		{"default uint", Lit(uint8(5)), "uint8(5)"},
+		{"hex", Lit(255).Hex(), "0xff"},
+		{"negative hex", Lit(int64(-255)).Hex(), "int64(-0xff)"},
+		{"octal", Lit(0755).Octal(), "0o755"},
+		{"binary", Lit(uint8(5)).Binary(), "uint8(0b101)"},
+		{"decimal separators", Lit(1000000).DigitSeparators(), "1_000_000"},
+		{"hex separators", Lit(uint32(0xdeadbeef)).Hex().DigitSeparators(), "uint32(0xdead_beef)"},
+		{"short separators", Lit(100).DigitSeparators(), "100"},
+		{"untyped int64", Lit(int64(1)).Untyped(), "1"},
+		{"typed int", Lit(1).Typed(), "int(1)"},
+		{"typed float64", Lit(1.5).Typed(), "float64(1.5)"},
+		{"untyped float32", Lit(float32(1.5)).Untyped(), "1.5"},
+	}
+
+	for _, v := range cases {
+		t.Run(v.name, func(t *testing.T) {
+			if got := v.input.String(); got != v.expect {
+				t.Errorf("Expected %s, got %s", v.expect, got)
+			}
+		})
+	}
+}
+
 func TestFormatComment(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -3,25 +3,35 @@ package gg
 import "io"
 
 type istruct struct {
-	name  string
-	items *Group
+	name       string
+	typeParams *Group
+	items      *Group
 }
 
 // Struct will insert a new struct.
 func Struct(name string) *istruct {
 	return &istruct{
-		name: name,
-		// We will insert new line before closing the struct to avoid being affect
-		// by line comments.
-		items: newGroup("{", "\n}", "\n"),
+		name:       name,
+		typeParams: newTypeParams(),
+		// We will insert new line after opening and before closing the struct
+		// to avoid being affect by line comments.
+		items: newGroup("{\n", "\n}", "\n"),
 	}
 }
 
 func (i *istruct) render(w io.Writer) {
-	writeStringF(w, "type %s struct", i.name)
+	writeStringF(w, "type %s", i.name)
+	i.typeParams.render(w)
+	writeString(w, " struct")
 	i.items.render(w)
 }
 
+// AddTypeParameter adds a type parameter, like `type Pair[K comparable, V any] struct`.
+func (i *istruct) AddTypeParameter(name, constraint interface{}) *istruct {
+	i.typeParams.append(field(name, constraint, " "))
+	return i
+}
+
 // AddLine will insert an empty line.
 func (i *istruct) AddLine() *istruct {
 	i.items.append(Line())
@@ -3,27 +3,48 @@ package gg
 import "io"
 
 type itype struct {
-	name string
-	item Node
-	sep  string
+	name       string
+	typeParams *Group
+	item       Node
+	sep        string
 }
 
 func Type(name string, typ interface{}) *itype {
 	return &itype{
-		name: name,
-		item: parseNode(typ),
+		name:       name,
+		typeParams: newTypeParams(),
+		item:       parseNode(typ),
 	}
 }
 
 func TypeAlias(name string, typ interface{}) *itype {
 	return &itype{
-		name: name,
-		item: parseNode(typ),
-		sep:  "=",
+		name:       name,
+		typeParams: newTypeParams(),
+		item:       parseNode(typ),
+		sep:        "=",
 	}
 }
 
 func (i *itype) render(w io.Writer) {
-	writeStringF(w, "type %s %s", i.name, i.sep)
+	writeStringF(w, "type %s", i.name)
+	i.typeParams.render(w)
+	writeStringF(w, " %s", i.sep)
 	i.item.render(w)
 }
+
+// AddTypeParameter adds a type parameter, like `type List[T any] []T`.
+func (i *itype) AddTypeParameter(name, constraint interface{}) *itype {
+	i.typeParams.append(field(name, constraint, " "))
+	return i
+}
+
+// newTypeParams creates the group for a type parameter list, which will be
+// omitted while there is no type parameter.
+func newTypeParams() *Group {
+	g := newGroup("[", "]", ", ")
+	g.omitWrapIf = func(g *Group) bool {
+		return g.length() == 0
+	}
+	return g
+}
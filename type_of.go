@@ -0,0 +1,364 @@
+package gg
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ReflectType returns a type node for t, registering the package of named
+// types through the generator. Types of the generator's own package (see
+// SetImportPath) and of package main, which could not be imported, are not
+// qualified.
+//
+// Example:
+//
+//	gen.ReflectType(reflect.TypeOf(map[string][]*url.URL{}))
+//	// => map[string][]*url.URL
+func (g *Generator) ReflectType(t reflect.Type) Node {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return String(t.Name())
+		}
+		name, args, ok := strings.Cut(t.Name(), "[")
+		if !ok {
+			return g.typeRef(t.PkgPath(), name)
+		}
+		// Instantiated generic types only expose their type arguments by
+		// name, like `Pair[int,github.com/example/types.User]`.
+		return &genericType{
+			base: g.typeRef(t.PkgPath(), name),
+			args: g.typeStrings(strings.TrimSuffix(args, "]")),
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return &ptrType{elem: g.ReflectType(t.Elem())}
+	case reflect.Slice:
+		return &sliceType{elem: g.ReflectType(t.Elem())}
+	case reflect.Array:
+		return concat(fmt.Sprintf("[%d]", t.Len()), g.ReflectType(t.Elem()))
+	case reflect.Map:
+		return &mapType{key: g.ReflectType(t.Key()), value: g.ReflectType(t.Elem())}
+	case reflect.Chan:
+		dir := chanBoth
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			dir = chanRecv
+		case reflect.SendDir:
+			dir = chanSend
+		}
+		return &chanType{elem: g.ReflectType(t.Elem()), dir: dir}
+	case reflect.Func:
+		return concat("func", g.reflectSignature(t))
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return Any()
+		}
+		n := concat("interface{")
+		for i := 0; i < t.NumMethod(); i++ {
+			if i > 0 {
+				n.Append("; ")
+			}
+			m := t.Method(i)
+			n.Append(m.Name, g.reflectSignature(m.Type))
+		}
+		return n.Append("}")
+	case reflect.Struct:
+		n := concat("struct{")
+		for i := 0; i < t.NumField(); i++ {
+			if i > 0 {
+				n.Append("; ")
+			}
+			sf := t.Field(i)
+			if !sf.Anonymous {
+				n.Append(sf.Name, " ")
+			}
+			n.Append(g.ReflectType(sf.Type))
+			if sf.Tag != "" {
+				n.Append(" ", strconv.Quote(string(sf.Tag)))
+			}
+		}
+		return n.Append("}")
+	}
+	return String(t.String())
+}
+
+// reflectSignature renders the parameters and results of a func type.
+func (g *Generator) reflectSignature(t reflect.Type) Node {
+	n := concat("(")
+	for i := 0; i < t.NumIn(); i++ {
+		if i > 0 {
+			n.Append(", ")
+		}
+		in := t.In(i)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			n.Append("...", g.ReflectType(in.Elem()))
+			continue
+		}
+		n.Append(g.ReflectType(in))
+	}
+	n.Append(")")
+
+	switch t.NumOut() {
+	case 0:
+	case 1:
+		n.Append(" ", g.ReflectType(t.Out(0)))
+	default:
+		n.Append(" (")
+		for i := 0; i < t.NumOut(); i++ {
+			if i > 0 {
+				n.Append(", ")
+			}
+			n.Append(g.ReflectType(t.Out(i)))
+		}
+		n.Append(")")
+	}
+	return n
+}
+
+// typeRef returns the named type of the package, a type of package main is
+// not qualified like a type of the generator's own package, since the
+// package could not be imported.
+func (g *Generator) typeRef(pkgPath, name string) Node {
+	if pkgPath == "main" {
+		return String(name)
+	}
+	return g.P(pkgPath).Type(name)
+}
+
+// typeStrings converts a comma separated list of type names, which are
+// qualified by the full import path, like `[]github.com/example/types.User`.
+func (g *Generator) typeStrings(list string) []Node {
+	var out []Node
+	depth, start := 0, 0
+	for i, r := range list {
+		switch r {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, g.typeString(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(out, g.typeString(list[start:]))
+}
+
+// typeString converts a type name qualified by the full import path.
+func (g *Generator) typeString(s string) Node {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "*"):
+		return &ptrType{elem: g.typeString(s[1:])}
+	case strings.HasPrefix(s, "[]"):
+		return &sliceType{elem: g.typeString(s[2:])}
+	case strings.HasPrefix(s, "["):
+		size, elem, _ := strings.Cut(s[1:], "]")
+		return concat("[", size, "]", g.typeString(elem))
+	case strings.HasPrefix(s, "map["):
+		depth := 0
+		for i := 3; i < len(s); i++ {
+			switch s[i] {
+			case '[':
+				depth++
+			case ']':
+				depth--
+			}
+			if depth == 0 {
+				return &mapType{key: g.typeString(s[4:i]), value: g.typeString(s[i+1:])}
+			}
+		}
+	case strings.HasPrefix(s, "<-chan "):
+		return &chanType{elem: g.typeString(s[7:]), dir: chanRecv}
+	case strings.HasPrefix(s, "chan<- "):
+		return &chanType{elem: g.typeString(s[7:]), dir: chanSend}
+	case strings.HasPrefix(s, "chan "):
+		return &chanType{elem: g.typeString(s[5:]), dir: chanBoth}
+	case strings.HasPrefix(s, "func"), strings.HasPrefix(s, "struct"), strings.HasPrefix(s, "interface"):
+		// Literal types could not be resolved from their name.
+		return String(s)
+	}
+
+	name, args, generic := strings.Cut(s, "[")
+	var base Node = String(name)
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		base = g.typeRef(name[:idx], name[idx+1:])
+	}
+	if !generic {
+		return base
+	}
+	return &genericType{base: base, args: g.typeStrings(strings.TrimSuffix(args, "]"))}
+}
+
+// GoType returns a type node for a go/types type, like the types loaded by
+// golang.org/x/tools/go/packages. Packages of named types are registered
+// through the generator, and types of the generator's own package (see
+// SetImportPath) and of package main are not qualified.
+//
+// Example:
+//
+//	obj := pkg.Types.Scope().Lookup("Handler")
+//	gen.GoType(obj.Type())                  // => handler.Handler
+//	gen.GoType(types.NewPointer(obj.Type())) // => *handler.Handler
+func (g *Generator) GoType(t types.Type) Node {
+	switch t := t.(type) {
+	case *types.Basic:
+		if t.Kind() == types.UnsafePointer {
+			return g.P("unsafe").Type("Pointer")
+		}
+		return String(t.Name())
+	case *types.Pointer:
+		return &ptrType{elem: g.GoType(t.Elem())}
+	case *types.Slice:
+		return &sliceType{elem: g.GoType(t.Elem())}
+	case *types.Array:
+		return concat(fmt.Sprintf("[%d]", t.Len()), g.GoType(t.Elem()))
+	case *types.Map:
+		return &mapType{key: g.GoType(t.Key()), value: g.GoType(t.Elem())}
+	case *types.Chan:
+		dir := chanBoth
+		switch t.Dir() {
+		case types.RecvOnly:
+			dir = chanRecv
+		case types.SendOnly:
+			dir = chanSend
+		}
+		return &chanType{elem: g.GoType(t.Elem()), dir: dir}
+	case *types.Signature:
+		return concat("func", g.goSignature(t))
+	case *types.TypeParam:
+		return String(t.Obj().Name())
+	case *types.Struct:
+		n := concat("struct{")
+		for i := 0; i < t.NumFields(); i++ {
+			if i > 0 {
+				n.Append("; ")
+			}
+			f := t.Field(i)
+			if !f.Embedded() {
+				n.Append(f.Name(), " ")
+			}
+			n.Append(g.GoType(f.Type()))
+			if tag := t.Tag(i); tag != "" {
+				n.Append(" ", strconv.Quote(tag))
+			}
+		}
+		return n.Append("}")
+	case *types.Interface:
+		return g.goInterface(t)
+	case *types.Union:
+		n := concat()
+		for i := 0; i < t.Len(); i++ {
+			if i > 0 {
+				n.Append(" | ")
+			}
+			term := t.Term(i)
+			if term.Tilde() {
+				n.Append("~")
+			}
+			n.Append(g.GoType(term.Type()))
+		}
+		return n
+	case *types.Tuple:
+		return g.goTuple(t, false)
+	}
+
+	// Named types and aliases.
+	if named, ok := t.(interface {
+		Obj() *types.TypeName
+		TypeArgs() *types.TypeList
+	}); ok {
+		obj := named.Obj()
+		var base Node = String(obj.Name())
+		if obj.Pkg() != nil && obj.Pkg().Name() != "main" {
+			base = g.P(obj.Pkg().Path()).Type(obj.Name())
+		}
+		if named.TypeArgs().Len() == 0 {
+			return base
+		}
+		args := make([]Node, 0, named.TypeArgs().Len())
+		for i := 0; i < named.TypeArgs().Len(); i++ {
+			args = append(args, g.GoType(named.TypeArgs().At(i)))
+		}
+		return &genericType{base: base, args: args}
+	}
+
+	return String(types.TypeString(t, func(p *types.Package) string {
+		if p.Name() == "main" {
+			return ""
+		}
+		return g.P(p.Path()).Alias()
+	}))
+}
+
+func (g *Generator) goInterface(t *types.Interface) Node {
+	if t.NumEmbeddeds() == 0 && t.NumExplicitMethods() == 0 {
+		if t.IsComparable() {
+			return String("comparable")
+		}
+		return Any()
+	}
+	n := concat("interface{")
+	first := true
+	for i := 0; i < t.NumEmbeddeds(); i++ {
+		if !first {
+			n.Append("; ")
+		}
+		first = false
+		n.Append(g.GoType(t.EmbeddedType(i)))
+	}
+	for i := 0; i < t.NumExplicitMethods(); i++ {
+		if !first {
+			n.Append("; ")
+		}
+		first = false
+		m := t.ExplicitMethod(i)
+		n.Append(m.Name(), g.goSignature(m.Type().(*types.Signature)))
+	}
+	return n.Append("}")
+}
+
+// goSignature renders the parameters and results of a func type, parameter
+// names are kept.
+func (g *Generator) goSignature(t *types.Signature) Node {
+	n := concat(g.goTuple(t.Params(), t.Variadic()))
+
+	results := t.Results()
+	switch {
+	case results.Len() == 0:
+	case results.Len() == 1 && results.At(0).Name() == "":
+		n.Append(" ", g.GoType(results.At(0).Type()))
+	default:
+		n.Append(" ", g.goTuple(results, false))
+	}
+	return n
+}
+
+// goTuple renders a parenthesized list of parameters or results.
+func (g *Generator) goTuple(t *types.Tuple, variadic bool) Node {
+	n := concat("(")
+	for i := 0; i < t.Len(); i++ {
+		if i > 0 {
+			n.Append(", ")
+		}
+		v := t.At(i)
+		if v.Name() != "" {
+			n.Append(v.Name(), " ")
+		}
+		if variadic && i == t.Len()-1 {
+			if s, ok := v.Type().(*types.Slice); ok {
+				n.Append("...", g.GoType(s.Elem()))
+				continue
+			}
+		}
+		n.Append(g.GoType(v.Type()))
+	}
+	return n.Append(")")
+}
@@ -0,0 +1,176 @@
+package gg
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type typeOfPair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func TestReflectType(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      reflect.Type
+		expected string
+	}{
+		{"basic", reflect.TypeOf(0), "int"},
+		{"named", reflect.TypeOf(time.Duration(0)), "time.Duration"},
+		{"pointer", reflect.TypeOf(&url.URL{}), "*url.URL"},
+		{"map", reflect.TypeOf(map[string][]*url.URL{}), "map[string][]*url.URL"},
+		{"array", reflect.TypeOf([2]byte{}), "[2]uint8"},
+		{"chan", reflect.TypeOf(make(<-chan time.Time)), "<-chan time.Time"},
+		{"func", reflect.TypeOf(func(string, ...int) (bool, error) { return false, nil }), "func(string, ...int) (bool, error)"},
+		{"interface", reflect.TypeOf((*io.Reader)(nil)).Elem(), "io.Reader"},
+		{"anonymous struct", reflect.TypeOf(struct {
+			A int `json:"a"`
+		}{}), "struct{A int \"json:\\\"a\\\"\"}"},
+		{"generic", reflect.TypeOf(typeOfPair[string, *url.URL]{}), "gg.typeOfPair[string, *url.URL]"},
+		{"nested generic", reflect.TypeOf(typeOfPair[int, map[string]typeOfPair[int, time.Time]]{}), "gg.typeOfPair[int, map[string]gg.typeOfPair[int, time.Time]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := New()
+			compareAST(t, tt.expected, renderString(gen.ReflectType(tt.typ)))
+		})
+	}
+}
+
+func TestReflectType_Imports(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().NewVar().AddTypedField("m", gen.ReflectType(reflect.TypeOf(map[string]*url.URL{})), "nil")
+
+	output := gen.String()
+	if !strings.Contains(output, `"net/url"`) {
+		t.Errorf("Expected net/url import, got:\n%s", output)
+	}
+}
+
+const typeOfSource = `package models
+
+import (
+	"context"
+	"io"
+)
+
+type List[T any] []T
+
+type Number interface {
+	~int | ~float64
+}
+
+type Store interface {
+	io.Closer
+	Load(ctx context.Context, ids ...string) (items List[*Item], err error)
+}
+
+type Item struct {
+	ID   int    ` + "`json:\"id\"`" + `
+	Tags map[string]chan<- int
+	io.Reader
+}
+`
+
+func loadTypeOfPackage(t *testing.T) *types.Package {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "models.go", typeOfSource, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("github.com/example/models", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestGoType(t *testing.T) {
+	pkg := loadTypeOfPackage(t)
+	lookup := func(name string) types.Type {
+		return pkg.Scope().Lookup(name).Type()
+	}
+	list := lookup("List").(*types.Named)
+	item := lookup("Item")
+	instance, err := types.Instantiate(nil, list, []types.Type{types.NewPointer(item)}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		typ      types.Type
+		expected string
+	}{
+		{"named", item, "models.Item"},
+		{"pointer", types.NewPointer(item), "*models.Item"},
+		{"instance", instance, "models.List[*models.Item]"},
+		{"constraint", lookup("Number").Underlying(), "interface{~int | ~float64}"},
+		{"struct", item.Underlying(), "struct{ID int \"json:\\\"id\\\"\"; Tags map[string]chan<- int; io.Reader}"},
+		{"interface", lookup("Store").Underlying(), "interface{io.Closer; Load(ctx context.Context, ids ...string) (items models.List[*models.Item], err error)}"},
+		{"unsafe", types.Typ[types.UnsafePointer], "unsafe.Pointer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := New()
+			compareAST(t, tt.expected, renderString(gen.GoType(tt.typ)))
+		})
+	}
+}
+
+func TestGoType_Main(t *testing.T) {
+	main := types.NewPackage("example.com/cmd/tool", "main")
+	config := types.NewNamed(types.NewTypeName(0, main, "Config", nil), types.NewStruct(nil, nil), nil)
+
+	gen := New()
+	gen.SetPackage("main")
+	if got := renderString(gen.GoType(types.NewPointer(config))); got != "*Config" {
+		t.Errorf("Expected *Config, got %s", got)
+	}
+	if got := renderString(gen.typeString("map[string]main.Config")); got != "map[string]Config" {
+		t.Errorf("Expected map[string]Config, got %s", got)
+	}
+	if len(gen.Imports()) != 0 {
+		t.Errorf("Expected package main not to be imported, got %v", gen.Imports())
+	}
+}
+
+func TestSetImportPath(t *testing.T) {
+	pkg := loadTypeOfPackage(t)
+	item := pkg.Scope().Lookup("Item").Type()
+
+	gen := New()
+	gen.SetPackage("models")
+	before := gen.P("github.com/example/models").Type("Store")
+	gen.SetImportPath("github.com/example/models")
+
+	gen.Body().NewVar().
+		AddTypedField("a", gen.GoType(types.NewSlice(item)), "nil").
+		AddTypedField("b", before, "nil").
+		AddTypedField("c", gen.P("context").Type("Context"), "nil")
+
+	output := gen.String()
+	if !strings.Contains(output, "a []Item") || !strings.Contains(output, "b Store") {
+		t.Errorf("Expected unqualified local types, got:\n%s", output)
+	}
+	if strings.Contains(output, `"github.com/example/models"`) {
+		t.Errorf("Own package should not be imported, got:\n%s", output)
+	}
+	if !strings.Contains(output, `"context"`) {
+		t.Errorf("Expected context import, got:\n%s", output)
+	}
+}
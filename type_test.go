@@ -24,3 +24,14 @@ func TestType(t *testing.T) {
 		compareAST(t, expected, buf.String())
 	})
 }
+
+func TestTypeParameters(t *testing.T) {
+	buf := pool.Get()
+	defer buf.Free()
+
+	expected := "type List[T any] []T"
+
+	Type("List", "[]T").AddTypeParameter("T", "any").render(buf)
+
+	compareAST(t, expected, buf.String())
+}
@@ -0,0 +1,155 @@
+package gg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	userRegionBegin = "// gg:user-begin "
+	userRegionEnd   = "// gg:user-end "
+)
+
+// iregion is a hand-written region of a generated file.
+type iregion struct {
+	name string
+	body *Group
+}
+
+// UserRegion creates a region which could be edited by hand. The region is
+// rendered between marker comments with the default content, and when the
+// file is written over an existing one, the content between the markers of
+// the old file is kept verbatim.
+//
+// Writing fails if a region of the old file is missing in the output, or its
+// markers have been changed. It panics if the name is empty or contains
+// spaces.
+//
+// Example:
+//
+//	fn.AddBody(
+//		gg.UserRegion("validate", "return nil"),
+//	)
+//	// =>
+//	// gg:user-begin validate
+//	return nil
+//	// gg:user-end validate
+func UserRegion(name string, defaults ...any) *iregion {
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		panic(fmt.Sprintf("invalid user region name %q", name))
+	}
+	r := &iregion{name: name, body: NewGroup()}
+	r.body.append(defaults...)
+	return r
+}
+
+// AddBody appends default content to the region.
+func (r *iregion) AddBody(node ...any) *iregion {
+	r.body.append(node...)
+	return r
+}
+
+func (r *iregion) render(w io.Writer) {
+	writeString(w, userRegionBegin+r.name+"\n")
+	if r.body.length() > 0 {
+		r.body.render(w)
+		writeString(w, "\n")
+	}
+	// The end marker is a line comment, it must not be followed by code.
+	writeString(w, userRegionEnd+r.name+"\n")
+}
+
+// AddUserRegion appends a region which could be edited by hand, see UserRegion.
+func (g *Group) AddUserRegion(name string, defaults ...any) *Group {
+	g.append(UserRegion(name, defaults...))
+	return g
+}
+
+// regionSpan is a user region found in a source file, lines[start:end] is
+// the content between the markers.
+type regionSpan struct {
+	name       string
+	start, end int
+}
+
+// scanUserRegions returns the lines of the source and its user regions.
+func scanUserRegions(src []byte) ([]string, []regionSpan, error) {
+	lines := splitLines(string(src))
+	var spans []regionSpan
+	seen := make(map[string]bool)
+	var open *regionSpan
+	for i, line := range lines {
+		text := strings.TrimSpace(line) + " "
+		switch {
+		case strings.HasPrefix(text, userRegionBegin):
+			name := strings.TrimSpace(text[len(userRegionBegin):])
+			if open != nil {
+				return nil, nil, fmt.Errorf("user region %q: begin marker of %q before the end marker (line %d)", open.name, name, i+1)
+			}
+			if seen[name] {
+				return nil, nil, fmt.Errorf("user region %q: declared twice (line %d)", name, i+1)
+			}
+			seen[name] = true
+			open = &regionSpan{name: name, start: i + 1}
+		case strings.HasPrefix(text, userRegionEnd):
+			name := strings.TrimSpace(text[len(userRegionEnd):])
+			if open == nil || open.name != name {
+				return nil, nil, fmt.Errorf("user region %q: end marker without begin marker (line %d)", name, i+1)
+			}
+			open.end = i
+			spans = append(spans, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		return nil, nil, fmt.Errorf("user region %q: missing end marker", open.name)
+	}
+	return lines, spans, nil
+}
+
+// restoreUserRegions copies the content of the user regions in the existing
+// file into the generated output.
+func restoreUserRegions(existing, output []byte) ([]byte, error) {
+	lines, spans, err := scanUserRegions(output)
+	if err != nil {
+		return nil, fmt.Errorf("generated code: %s", err)
+	}
+	if existing == nil {
+		return output, nil
+	}
+	oldLines, oldSpans, err := scanUserRegions(existing)
+	if err != nil {
+		return nil, fmt.Errorf("existing file: %s", err)
+	}
+	if len(oldSpans) == 0 {
+		return output, nil
+	}
+
+	old := make(map[string][]string, len(oldSpans))
+	for _, s := range oldSpans {
+		old[s.name] = oldLines[s.start:s.end]
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, s := range spans {
+		content, ok := old[s.name]
+		if !ok {
+			// A new region keeps its default content.
+			continue
+		}
+		delete(old, s.name)
+		out.WriteString(strings.Join(lines[last:s.start], ""))
+		out.WriteString(strings.Join(content, ""))
+		last = s.end
+	}
+	out.WriteString(strings.Join(lines[last:], ""))
+
+	for _, s := range oldSpans {
+		if _, ok := old[s.name]; ok {
+			return nil, fmt.Errorf("user region %q of the existing file is missing in the generated code", s.name)
+		}
+	}
+	return []byte(out.String()), nil
+}
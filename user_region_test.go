@@ -0,0 +1,149 @@
+package gg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newRegionGenerator() *Generator {
+	gen := New()
+	gen.SetPackage("handler")
+	gen.Body().NewFunction("Validate").
+		AddParameter("u", "*User").
+		AddResult("", "error").
+		AddBody(
+			UserRegion("validate", Return("nil")),
+		)
+	return gen
+}
+
+func TestUserRegion_Render(t *testing.T) {
+	expected := `func Validate(u *User)(error){
+// gg:user-begin validate
+return nil
+// gg:user-end validate
+}`
+	if got := newRegionGenerator().Body().String(); got != expected {
+		t.Errorf("Unexpected output:\n%s", got)
+	}
+	if got := renderString(UserRegion("empty")); got != "// gg:user-begin empty\n// gg:user-end empty\n" {
+		t.Errorf("Unexpected output:\n%s", got)
+	}
+}
+
+func TestUserRegion_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "handler.go")
+	gen := newRegionGenerator()
+	if err := gen.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Edit the region by hand.
+	data, _ := os.ReadFile(path)
+	edited := strings.Replace(string(data), "return nil\n", "\tif u.Name == \"\" {\n\t\treturn errEmptyName\n\t}\n\treturn nil\n", 1)
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Regenerate with a new function and a new region.
+	gen = newRegionGenerator()
+	gen.Body().AddUserRegion("helpers", "// add helpers here")
+	res, err := gen.WriteFileWith(path, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(path)
+	if !res.Changed || !strings.Contains(string(data), "\tif u.Name == \"\" {\n\t\treturn errEmptyName\n\t}\n\treturn nil\n// gg:user-end validate") {
+		t.Errorf("Expected the user code to be kept, got:\n%s", data)
+	}
+	if !strings.Contains(string(data), "// gg:user-begin helpers\n// add helpers here\n") {
+		t.Errorf("Expected the default content of a new region, got:\n%s", data)
+	}
+
+	// Writing again doesn't change anything.
+	if res, err := gen.WriteFileWith(path, WriteOptions{}); err != nil || res.Changed {
+		t.Errorf("Expected the file not to be changed, got %+v, %v", res, err)
+	}
+}
+
+func TestUserRegion_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		gen      *Generator
+		err      string
+	}{
+		{
+			name:     "missing region",
+			existing: "// gg:user-begin old\ncode\n// gg:user-end old\n",
+			gen:      newRegionGenerator(),
+			err:      `user region "old" of the existing file is missing`,
+		},
+		{
+			name:     "missing end marker",
+			existing: "// gg:user-begin validate\ncode\n",
+			gen:      newRegionGenerator(),
+			err:      `existing file: user region "validate": missing end marker`,
+		},
+		{
+			name:     "renamed end marker",
+			existing: "// gg:user-begin validate\ncode\n// gg:user-end validation\n",
+			gen:      newRegionGenerator(),
+			err:      `user region "validation": end marker without begin marker (line 3)`,
+		},
+		{
+			name:     "nested begin marker",
+			existing: "// gg:user-begin a\n// gg:user-begin b\n",
+			gen:      newRegionGenerator(),
+			err:      `begin marker of "b" before the end marker`,
+		},
+		{
+			name: "duplicated region",
+			gen: func() *Generator {
+				gen := newRegionGenerator()
+				gen.Body().AddUserRegion("validate")
+				return gen
+			}(),
+			err: `generated code: user region "validate": declared twice`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "handler.go")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := tt.gen.WriteFileWith(path, WriteOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Expected error %q, got %v", tt.err, err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.existing {
+				t.Errorf("Expected the file not to be changed, got:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestUserRegion_Clone(t *testing.T) {
+	region := UserRegion("init", "setup()")
+	cloned := Clone(region).AddBody("teardown()")
+	if renderString(region) == renderString(cloned) {
+		t.Errorf("Expected the clone not to share the body")
+	}
+
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().AddUserRegion("types", Struct("User"))
+	file, err := gen.AST()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.File.Decls) != 1 || len(file.File.Comments) != 2 {
+		t.Errorf("Expected the region content and its markers, got %d decls and %d comments", len(file.File.Decls), len(file.File.Comments))
+	}
+}
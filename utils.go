@@ -3,6 +3,7 @@ package gg
 import (
 	"fmt"
 	"io"
+	"strings"
 )
 
 func writeString(w io.Writer, s ...string) {
@@ -21,3 +22,41 @@ func writeStringF(w io.Writer, format string, args ...interface{}) {
 		panic(fmt.Errorf("write string: %v", err))
 	}
 }
+
+// renderString renders the node into a string.
+func renderString(n Node) string {
+	buf := pool.Get()
+	defer buf.Free()
+	n.render(buf)
+	return buf.String()
+}
+
+// renderNested renders the node as a string with the generator of w, so
+// nodes which depend on it, like Any, are rendered as they are in w.
+func renderNested(w io.Writer, n Node) string {
+	ctx, ok := w.(*RenderContext)
+	if !ok {
+		return renderString(n)
+	}
+	buf := pool.Get()
+	defer buf.Free()
+	n.render(&RenderContext{w: buf, gen: ctx.gen, indent: ctx.indent, measuring: ctx.measuring})
+	return buf.String()
+}
+
+// isComment reports whether a text only contains comments.
+func isComment(text string) bool {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "/*") && strings.HasSuffix(text, "*/") {
+		return true
+	}
+	if text == "" {
+		return false
+	}
+	for _, l := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(l), "//") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,341 @@
+package gg
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// Problem is a mistake in a node tree found by Validate.
+type Problem struct {
+	// Path describes the node, like `func Get > if > return`.
+	Path string
+	Node Node
+	Msg  string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.Msg
+	}
+	return p.Path + ": " + p.Msg
+}
+
+// ValidationError is returned by Validate when the node tree would render
+// invalid code.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		msgs = append(msgs, p.String())
+	}
+	return "invalid code: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the body of the generator, see Validate.
+func (g *Generator) Validate() error {
+	return Validate(g)
+}
+
+// Validate checks a node tree for mistakes which would render invalid code,
+// without type checking it:
+//
+//   - empty or keyword names of declarations, parameters and fields;
+//   - duplicate parameter names;
+//   - a variadic parameter which is not the last one;
+//   - a method with receiver in an interface;
+//   - named and unnamed parameters or results mixed together;
+//   - a composite literal with both keyed and positional elements;
+//   - generics while the generator targets a version before go1.18, see
+//     SetGoVersion.
+//
+// It returns a *ValidationError with all problems found.
+//
+// Example:
+//
+//	if err := gen.Validate(); err != nil {
+//		// invalid code: func Get: duplicate parameter id; ...
+//		return err
+//	}
+func Validate(root Node) error {
+	v := &validator{}
+	if g, ok := root.(*Generator); ok {
+		v.goVersion = g.goVersion
+	}
+	v.visit(root, false)
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	path      []string
+	problems  []Problem
+	goVersion string
+}
+
+// visit checks the node and its children, decl reports whether the node is
+// a top-level declaration.
+func (v *validator) visit(n Node, decl bool) {
+	if label := nodeLabel(n); label != "" {
+		v.path = append(v.path, label)
+		defer func() { v.path = v.path[:len(v.path)-1] }()
+	}
+	v.check(n, decl)
+
+	// Items of the body, and of plain groups in it, are declarations.
+	childDecl := false
+	switch n := n.(type) {
+	case *Generator:
+		childDecl = true
+	case *Group:
+		childDecl = decl && n.open == "" && n.close == "" && n.separator == "\n"
+	}
+	eachChild(n, func(child *Node) {
+		v.visit(*child, childDecl)
+	})
+}
+
+func (v *validator) report(n Node, format string, args ...any) {
+	v.problems = append(v.problems, Problem{
+		Path: strings.Join(v.path, " > "),
+		Node: n,
+		Msg:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) check(n Node, decl bool) {
+	switch n := n.(type) {
+	case *ifunction:
+		// A function without name is a function literal.
+		if n.name == "" && decl && n.call == nil {
+			v.report(n, "empty function name")
+		}
+		v.checkName(n, "function", n.name)
+		v.checkSignature(n, n.receiver, n.parameters, n.results)
+		v.checkTypeParams(n, n.typeParams)
+	case *isignature:
+		if n.name == "" {
+			v.report(n, "empty method name")
+		}
+		v.checkName(n, "method", n.name)
+		v.checkSignature(n, nil, n.parameters, n.results)
+	case *istruct:
+		v.checkTypeName(n, n.name)
+		v.checkTypeParams(n, n.typeParams)
+		for _, item := range n.items.items {
+			if f, ok := item.(*ifield); ok {
+				for _, name := range fieldNames(f.name) {
+					v.checkName(n, "field", name)
+				}
+			}
+		}
+	case *iinterface:
+		v.checkTypeName(n, n.name)
+		v.checkTypeParams(n, n.typeParams)
+		for _, item := range n.items.items {
+			if f, ok := item.(*ifunction); ok && f.receiver != nil {
+				v.report(n, "method %s has a receiver", f.name)
+			}
+		}
+	case *itype:
+		v.checkTypeName(n, n.name)
+		v.checkTypeParams(n, n.typeParams)
+	case *genericType:
+		v.checkGenerics(n, "type arguments")
+	case *ivar:
+		v.checkValueNames(n, "variable", n.items)
+	case *iconst:
+		v.checkValueNames(n, "constant", n.items)
+	case *ivalue:
+		var keyed, positional bool
+		for _, item := range n.items.items {
+			if f, ok := item.(*ifield); ok && f.separator == ":" {
+				keyed = true
+			} else if !isTrivia(item) {
+				positional = true
+			}
+		}
+		if keyed && positional {
+			v.report(n, "mixed keyed and positional elements")
+		}
+	}
+}
+
+func (v *validator) checkTypeParams(n Node, typeParams *Group) {
+	if typeParams.length() > 0 {
+		v.checkGenerics(n, "type parameters")
+	}
+}
+
+// checkGenerics reports generics used by a generator which targets a version
+// before go1.18.
+func (v *validator) checkGenerics(n Node, what string) {
+	if minor, ok := parseGoVersion(v.goVersion); ok && minor < 18 {
+		v.report(n, "%s require go1.18, the target is go%s", what, v.goVersion)
+	}
+}
+
+func (v *validator) checkTypeName(n Node, name string) {
+	if name == "" {
+		v.report(n, "empty type name")
+	}
+	v.checkName(n, "type", name)
+}
+
+func (v *validator) checkName(n Node, kind, name string) {
+	if token.IsKeyword(name) {
+		v.report(n, "%s name %s is a keyword", kind, name)
+	}
+}
+
+func (v *validator) checkValueNames(n Node, kind string, items *Group) {
+	for _, item := range items.items {
+		f, ok := item.(*ifield)
+		if !ok {
+			continue
+		}
+		names := fieldNames(f.name)
+		if len(names) == 0 {
+			v.report(n, "empty %s name", kind)
+		}
+		for _, name := range names {
+			v.checkName(n, kind, name)
+		}
+	}
+}
+
+// param is a parameter or result of a signature.
+type param struct {
+	name, typ string
+}
+
+// checkSignature checks the receiver, parameters and results together, they
+// share one scope.
+func (v *validator) checkSignature(n Node, receiver Node, params, results *Group) {
+	seen := make(map[string]bool)
+	declare := func(kind, name string) {
+		if name == "" || name == "_" {
+			return
+		}
+		v.checkName(n, kind, name)
+		if seen[name] {
+			v.report(n, "duplicate %s %s", kind, name)
+		}
+		seen[name] = true
+	}
+	if f, ok := receiver.(*ifield); ok {
+		for _, name := range fieldNames(f.name) {
+			declare("receiver", name)
+		}
+	}
+
+	for _, list := range []struct {
+		kind  string
+		items *Group
+	}{{"parameter", params}, {"result", results}} {
+		fields := signatureParams(list.items)
+		named := 0
+		for i, p := range fields {
+			if p.name != "" {
+				named++
+			}
+			if strings.HasPrefix(p.typ, "...") {
+				if list.kind == "result" {
+					v.report(n, "variadic result %s", p.typ)
+				} else if i < len(fields)-1 {
+					v.report(n, "variadic parameter %s must be the last one", p.typ)
+				}
+			}
+			declare(list.kind, p.name)
+		}
+		if named > 0 && named < len(fields) {
+			v.report(n, "mixed named and unnamed %ss", list.kind)
+		}
+	}
+}
+
+// signatureParams returns the fields of a parameter or result list.
+func signatureParams(g *Group) []param {
+	var params []param
+	for _, item := range g.items {
+		switch f := item.(type) {
+		case *ifield:
+			typ := strings.TrimSpace(renderString(f.value))
+			names := fieldNames(f.name)
+			if len(names) == 0 {
+				params = append(params, param{typ: typ})
+			}
+			for _, name := range names {
+				params = append(params, param{name: name, typ: typ})
+			}
+		case *multiNameField:
+			typ := strings.TrimSpace(renderString(f.typ))
+			for _, name := range f.names {
+				params = append(params, param{name: strings.TrimSpace(name), typ: typ})
+			}
+		}
+	}
+	return params
+}
+
+// fieldNames returns the names of a field, like `a, b`.
+func fieldNames(n Node) []string {
+	if n == nil {
+		return nil
+	}
+	var names []string
+	for _, name := range strings.Split(renderString(n), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// nodeLabel describes a node in a problem path, it is empty for nodes which
+// are not shown, like groups and fields.
+func nodeLabel(n Node) string {
+	return strings.TrimSpace(nodeKind(n))
+}
+
+func nodeKind(n Node) string {
+	switch n := n.(type) {
+	case *ifunction:
+		if n.name == "" {
+			return "func literal"
+		}
+		return "func " + DeclName(n)
+	case *isignature:
+		return "method " + n.name
+	case *istruct:
+		return "struct " + n.name
+	case *iinterface:
+		return "interface " + n.name
+	case *itype:
+		return "type " + n.name
+	case *ivar:
+		return "var " + DeclName(n)
+	case *iconst:
+		return "const " + DeclName(n)
+	case *ivalue:
+		return "value " + strings.TrimSpace(renderString(n.typ))
+	case *iif:
+		return "if"
+	case *ifor:
+		return "for"
+	case *iswitch:
+		return "switch"
+	case *icase:
+		return "case"
+	case *ireturn:
+		return "return"
+	case *iregion:
+		return "region " + n.name
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package gg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	body := gen.Body()
+	body.NewFunction("Get").
+		WithReceiver("s", "*Store").
+		AddParameter("s", "int").
+		AddParameter("ids", "...int").
+		AddParameter("ctx", "context.Context").
+		AddResult("n", "int").
+		AddResult("", "error")
+	body.NewFunction("").AddBody(
+		If("ok").AddBody(
+			Return(Value("User").AddField("ID", Lit(1)).AddElement(Lit("alice"))),
+		),
+	)
+	body.NewFunction("type")
+	body.NewStruct("User").AddField("func", "int")
+	body.NewInterface("Reader").NewFunction("").AddResult("", "...byte")
+	body.NewVar().AddField("", Lit(1))
+	body.AddType("", "int")
+
+	err := gen.Validate()
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("Expected a *ValidationError, got %v", err)
+	}
+	expected := []string{
+		"func Store.Get: duplicate parameter s",
+		"func Store.Get: variadic parameter ...int must be the last one",
+		"func Store.Get: mixed named and unnamed results",
+		"func literal: empty function name",
+		"func literal > if > return > value User: mixed keyed and positional elements",
+		"func type: function name type is a keyword",
+		"struct User: field name func is a keyword",
+		"interface Reader > method: empty method name",
+		"interface Reader > method: variadic result ...byte",
+		"var: empty variable name",
+		"type: empty type name",
+	}
+	if len(vErr.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(expected), len(vErr.Problems), err)
+	}
+	for i, p := range vErr.Problems {
+		if p.String() != expected[i] {
+			t.Errorf("Problem %d: expected %q, got %q", i, expected[i], p.String())
+		}
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	fn := gen.Body().NewFunction("Sum").
+		AddParameters([]string{"a", "b"}, "int").
+		AddParameter("_", "string").
+		AddParameter("rest", "...int").
+		AddResult("", "int")
+	fn.AddBody(
+		Function("").WithCall(),
+		Return(Value("Point").AddElement(Lit(1), Lit(2))),
+	)
+	if err := gen.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := Validate(fn); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
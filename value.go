@@ -23,7 +23,7 @@ type ivalue struct {
 func Value(typ any) *ivalue {
 	return &ivalue{
 		typ:   parseNode(typ),
-		items: newGroup("{", "}", ","),
+		items: newListGroup("{", "}", ","),
 	}
 }
 
@@ -110,7 +110,7 @@ type islice struct {
 func Slice(elemType any, elements ...any) *islice {
 	s := &islice{
 		elemType: parseNode(elemType),
-		items:    newGroup("{", "}", ", "),
+		items:    newListGroup("{", "}", ", "),
 	}
 	if len(elements) > 0 {
 		s.items.append(elements...)
@@ -195,7 +195,7 @@ func Array(size int, elemType any, elements ...any) *iarray {
 	a := &iarray{
 		size:     size,
 		elemType: parseNode(elemType),
-		items:    newGroup("{", "}", ", "),
+		items:    newListGroup("{", "}", ", "),
 	}
 	if len(elements) > 0 {
 		a.items.append(elements...)
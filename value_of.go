@@ -0,0 +1,429 @@
+package gg
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bigIntType   = reflect.TypeOf(big.Int{})
+)
+
+// valueContext describes where a converted value will be placed, which
+// decides how much type information the literal must carry.
+type valueContext int
+
+const (
+	// valueTop is a standalone expression, so the literal must be fully typed.
+	valueTop valueContext = iota
+	// valueTyped is a position with a known type (like a struct field value),
+	// so untyped constants and nil are allowed.
+	valueTyped
+	// valueElem is an element of a composite literal, so the composite type
+	// of the element can be elided as well.
+	valueElem
+)
+
+// ValueOf converts a Go value into an equivalent literal expression.
+//
+// Structs, pointers, slices, arrays, maps, time.Time, time.Duration, []byte,
+// big.Int and named types are walked recursively. Named types from other
+// packages are qualified through the Generator, so their imports are
+// registered automatically. Map keys are sorted to keep the output stable,
+// zero-valued struct fields are omitted, and unexported struct fields are
+// skipped because they can't be set from a composite literal.
+//
+// ValueOf panics on values that can't be expressed as a literal, such as
+// non-nil channels and functions, or cyclic pointers.
+//
+// Example:
+//
+//	gen.ValueOf(types.User{ID: 1, Tags: []string{"a"}})
+//	// => types.User{ID: 1, Tags: []string{"a"}}
+func (g *Generator) ValueOf(v any) Node {
+	vc := &valueConverter{gen: g, seen: make(map[uintptr]bool)}
+	return vc.convert(reflect.ValueOf(v), valueTop)
+}
+
+type valueConverter struct {
+	gen  *Generator
+	seen map[uintptr]bool
+}
+
+func (vc *valueConverter) convert(v reflect.Value, ctx valueContext) Node {
+	if !v.IsValid() {
+		return String("nil")
+	}
+
+	t := v.Type()
+	switch t {
+	case timeType:
+		return vc.timeValue(v.Interface().(time.Time))
+	case durationType:
+		return vc.durationValue(time.Duration(v.Int()), ctx)
+	case bigIntType:
+		b := v.Interface().(big.Int)
+		return concat("*", vc.bigIntValue(&b))
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return vc.basicValue(t, strconv.FormatBool(v.Bool()), ctx)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return vc.basicValue(t, strconv.FormatInt(v.Int(), 10), ctx)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return vc.basicValue(t, strconv.FormatUint(v.Uint(), 10), ctx)
+	case reflect.Float32, reflect.Float64:
+		return vc.floatValue(t, v.Float(), ctx)
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return vc.basicValue(t, fmt.Sprintf("complex(%s, %s)",
+			formatFloat(real(c), t.Bits()/2), formatFloat(imag(c), t.Bits()/2)), ctx)
+	case reflect.String:
+		return vc.basicValue(t, strconv.Quote(v.String()), ctx)
+	case reflect.Ptr:
+		return vc.ptrValue(v, ctx)
+	case reflect.Interface:
+		if v.IsNil() {
+			return vc.nilValue(t, ctx)
+		}
+		return vc.convert(v.Elem(), valueTop)
+	case reflect.Struct:
+		return vc.structValue(v, ctx)
+	case reflect.Slice:
+		return vc.sliceValue(v, ctx)
+	case reflect.Array:
+		return vc.arrayValue(v, ctx)
+	case reflect.Map:
+		return vc.mapValue(v, ctx)
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if v.IsNil() {
+			return vc.nilValue(t, ctx)
+		}
+	}
+	panic(fmt.Sprintf("unsupported value for ValueOf: %s", t))
+}
+
+// basicValue renders a constant, adding a conversion when the constant's
+// default type doesn't match the value's type.
+func (vc *valueConverter) basicValue(t reflect.Type, text string, ctx valueContext) Node {
+	if ctx != valueTop || isDefaultType(t) {
+		return String(text)
+	}
+	return concat(vc.gen.ReflectType(t), "(", text, ")")
+}
+
+func (vc *valueConverter) floatValue(t reflect.Type, f float64, ctx valueContext) Node {
+	var text string
+	switch {
+	case math.IsInf(f, 1):
+		text = vc.gen.P("math").Call("Inf", Lit(1)).String()
+		ctx = valueTop
+	case math.IsInf(f, -1):
+		text = vc.gen.P("math").Call("Inf", Lit(-1)).String()
+		ctx = valueTop
+	case math.IsNaN(f):
+		text = vc.gen.P("math").Call("NaN").String()
+		ctx = valueTop
+	default:
+		text = formatFloat(f, t.Bits())
+	}
+	return vc.basicValue(t, text, ctx)
+}
+
+func (vc *valueConverter) nilValue(t reflect.Type, ctx valueContext) Node {
+	if ctx != valueTop {
+		return String("nil")
+	}
+	if t.Name() == "" {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Func, reflect.Chan:
+			return concat("(", vc.gen.ReflectType(t), ")(nil)")
+		}
+	}
+	return concat(vc.gen.ReflectType(t), "(nil)")
+}
+
+func (vc *valueConverter) ptrValue(v reflect.Value, ctx valueContext) Node {
+	t := v.Type()
+	if v.IsNil() {
+		return vc.nilValue(t, ctx)
+	}
+	if t.Elem() == bigIntType {
+		return vc.bigIntValue(v.Interface().(*big.Int))
+	}
+
+	addr := v.Pointer()
+	if vc.seen[addr] {
+		panic(fmt.Sprintf("cyclic value for ValueOf: %s", t))
+	}
+	vc.seen[addr] = true
+	defer delete(vc.seen, addr)
+
+	elem := v.Elem()
+	switch elem.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		if elem.Type() != timeType && elem.Type() != bigIntType {
+			if ctx == valueElem && elem.Kind() == reflect.Struct {
+				// &T{...} can be elided to {...} inside composite literals.
+				return vc.convert(elem, valueElem)
+			}
+			return concat("&", vc.convert(elem, valueTop))
+		}
+	}
+
+	// The address of a non-composite literal can't be taken directly.
+	return concat("func() ", vc.gen.ReflectType(t), " { v := ",
+		vc.convert(elem, valueTop), "; return &v }()")
+}
+
+func (vc *valueConverter) structValue(v reflect.Value, ctx valueContext) Node {
+	if ctx == valueElem {
+		// Element type is implied by the enclosing composite literal.
+		return vc.structFields(Value(""), v)
+	}
+	return vc.structFields(Value(vc.gen.ReflectType(v.Type())), v)
+}
+
+func (vc *valueConverter) structFields(lit *ivalue, v reflect.Value) Node {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.IsZero() {
+			continue
+		}
+		lit.AddField(sf.Name, vc.convert(fv, valueTyped))
+	}
+	if lit.items.length() > 1 {
+		lit.MultiLine()
+	}
+	return lit
+}
+
+func (vc *valueConverter) sliceValue(v reflect.Value, ctx valueContext) Node {
+	t := v.Type()
+	if v.IsNil() {
+		return vc.nilValue(t, ctx)
+	}
+
+	if t.Elem().Kind() == reflect.Uint8 && t.Elem().PkgPath() == "" {
+		text := strconv.Quote(string(v.Bytes()))
+		if t.Name() == "" {
+			return concat("[]byte(", text, ")")
+		}
+		return concat(vc.gen.ReflectType(t), "(", text, ")")
+	}
+
+	elems := vc.elements(v)
+	if t.Name() == "" && ctx != valueElem {
+		s := Slice(vc.gen.ReflectType(t.Elem()), elems...)
+		if isCompositeKind(t.Elem()) && len(elems) > 1 {
+			s.MultiLine()
+		}
+		return s
+	}
+	return vc.compositeValue(t, elems, ctx)
+}
+
+func (vc *valueConverter) arrayValue(v reflect.Value, ctx valueContext) Node {
+	t := v.Type()
+	elems := vc.elements(v)
+	if t.Name() == "" && ctx != valueElem {
+		a := Array(t.Len(), vc.gen.ReflectType(t.Elem()), elems...)
+		if isCompositeKind(t.Elem()) && len(elems) > 1 {
+			a.MultiLine()
+		}
+		return a
+	}
+	return vc.compositeValue(t, elems, ctx)
+}
+
+func (vc *valueConverter) elements(v reflect.Value) []any {
+	elems := make([]any, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elems = append(elems, vc.convert(v.Index(i), valueElem))
+	}
+	return elems
+}
+
+func (vc *valueConverter) compositeValue(t reflect.Type, elems []any, ctx valueContext) Node {
+	var lit *ivalue
+	if ctx == valueElem {
+		lit = Value("")
+	} else {
+		lit = Value(vc.gen.ReflectType(t))
+	}
+	lit.AddElement(elems...)
+	if isCompositeKind(t.Elem()) && len(elems) > 1 {
+		lit.MultiLine()
+	}
+	return lit
+}
+
+func (vc *valueConverter) mapValue(v reflect.Value, ctx valueContext) Node {
+	t := v.Type()
+	if v.IsNil() {
+		return vc.nilValue(t, ctx)
+	}
+
+	var lit *ivalue
+	if ctx == valueElem {
+		lit = Value("")
+	} else {
+		lit = Value(vc.gen.ReflectType(t))
+	}
+
+	keys := v.MapKeys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return compareValues(keys[i], keys[j]) < 0
+	})
+	for _, k := range keys {
+		lit.AddField(vc.convert(k, valueElem), vc.convert(v.MapIndex(k), valueElem))
+	}
+	if len(keys) > 1 {
+		lit.MultiLine()
+	}
+	return lit
+}
+
+func (vc *valueConverter) timeValue(t time.Time) Node {
+	pkg := vc.gen.P("time")
+	if t.IsZero() {
+		return concat(pkg.Type("Time"), "{}")
+	}
+
+	var loc Node
+	switch t.Location() {
+	case time.UTC:
+		loc = pkg.Dot("UTC")
+	case time.Local:
+		loc = pkg.Dot("Local")
+	default:
+		// Named locations can't be loaded without error handling, so we
+		// keep the zone name and offset which is enough to restore the instant.
+		name, offset := t.Zone()
+		loc = pkg.Call("FixedZone", Lit(name), Lit(offset))
+	}
+	return pkg.Call("Date",
+		Lit(t.Year()), pkg.Dot(t.Month().String()), Lit(t.Day()),
+		Lit(t.Hour()), Lit(t.Minute()), Lit(t.Second()), Lit(t.Nanosecond()),
+		loc,
+	)
+}
+
+func (vc *valueConverter) durationValue(d time.Duration, ctx valueContext) Node {
+	pkg := vc.gen.P("time")
+	if d == 0 {
+		if ctx != valueTop {
+			return String("0")
+		}
+		return concat(pkg.Type("Duration"), "(0)")
+	}
+
+	units := []struct {
+		name string
+		unit time.Duration
+	}{
+		{"Hour", time.Hour},
+		{"Minute", time.Minute},
+		{"Second", time.Second},
+		{"Millisecond", time.Millisecond},
+		{"Microsecond", time.Microsecond},
+		{"Nanosecond", time.Nanosecond},
+	}
+	for _, u := range units {
+		if d%u.unit != 0 {
+			continue
+		}
+		n := d / u.unit
+		if n == 1 {
+			return pkg.Dot(u.name)
+		}
+		return concat(strconv.FormatInt(int64(n), 10), " * ", pkg.Dot(u.name))
+	}
+	// Unreachable: every duration is a multiple of a nanosecond.
+	return concat(pkg.Type("Duration"), "(", strconv.FormatInt(int64(d), 10), ")")
+}
+
+func (vc *valueConverter) bigIntValue(b *big.Int) Node {
+	pkg := vc.gen.P("math/big")
+	if b.IsInt64() {
+		return pkg.Call("NewInt", strconv.FormatInt(b.Int64(), 10))
+	}
+	return concat("func() *", pkg.Type("Int"), " { v, _ := new(", pkg.Type("Int"),
+		").SetString(", strconv.Quote(b.String()), ", 10); return v }()")
+}
+
+// isDefaultType reports whether an untyped constant of t's kind would
+// default to t itself, so no conversion is needed.
+func isDefaultType(t reflect.Type) bool {
+	if t.PkgPath() != "" {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Float64, reflect.Complex128, reflect.String:
+		return true
+	}
+	return false
+}
+
+func isCompositeKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	case reflect.Ptr:
+		return t.Elem().Kind() == reflect.Struct
+	}
+	return false
+}
+
+func formatFloat(f float64, bits int) string {
+	out := strconv.FormatFloat(f, 'g', -1, bits)
+	for _, c := range out {
+		if c == '.' || c == 'e' {
+			return out
+		}
+	}
+	return out + ".0"
+}
+
+// compareValues orders map keys the same way for every run.
+func compareValues(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return cmp.Compare(boolToInt(a.Bool()), boolToInt(b.Bool()))
+	}
+	return cmp.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// concat joins nodes and strings into a single inline node.
+func concat(parts ...any) *Group {
+	return NewInlineGroup().Append(parts...)
+}
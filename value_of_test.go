@@ -0,0 +1,168 @@
+package gg
+
+import (
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type valueOfStatus int
+
+type valueOfUser struct {
+	ID      int64
+	Name    string
+	Tags    []string
+	Status  valueOfStatus
+	Parent  *valueOfUser
+	Extra   map[string]int
+	private string
+}
+
+func TestValueOf_Basic(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"nil", nil, "nil"},
+		{"bool", true, "true"},
+		{"int", 42, "42"},
+		{"int64", int64(42), "int64(42)"},
+		{"uint8", uint8(7), "uint8(7)"},
+		{"float64", 1.0, "1.0"},
+		{"float32", float32(1.5), "float32(1.5)"},
+		{"string", "a\"b", `"a\"b"`},
+		{"complex", complex(1, 2), "complex(1.0, 2.0)"},
+		{"bytes", []byte("hi"), `[]byte("hi")`},
+		{"int slice", []int{1, 2, 3}, "[]int{1, 2, 3}"},
+		{"nil slice", []int(nil), "[]int(nil)"},
+		{"nil pointer", (*int)(nil), "(*int)(nil)"},
+		{"array", [2]string{"a", "b"}, `[2]string{"a", "b"}`},
+		{"sorted map", map[string]int{"b": 2, "a": 1}, `map[string]int{"a": 1, "b": 2,}`},
+		{"interface slice", []any{1, int8(2), "x"}, `[]interface{}{1, int8(2), "x"}`},
+		{"pointer to int", func() *int { v := 3; return &v }(), "func() *int { v := 3; return &v }()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := New()
+
+			buf := pool.Get()
+			defer buf.Free()
+			gen.ValueOf(tt.value).render(buf)
+
+			compareAST(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestValueOf_Struct(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+
+	u := &valueOfUser{
+		ID:      1,
+		Name:    "Alice",
+		Tags:    []string{"admin"},
+		Status:  2,
+		Parent:  &valueOfUser{ID: 2},
+		Extra:   map[string]int{"z": 1, "a": 2},
+		private: "skipped",
+	}
+	gen.Body().NewVar().AddField("u", gen.ValueOf(u))
+
+	output := gen.String()
+
+	expected := `&gg.valueOfUser{
+ID: 1,
+Name: "Alice",
+Tags: []string{"admin"},
+Status: 2,
+Parent: &gg.valueOfUser{ID: 2},
+Extra: map[string]int{
+"a": 2,
+"z": 1,
+},
+}`
+	if !strings.Contains(cleanAST(output), cleanAST(expected)) {
+		t.Errorf("Expected struct literal, got:\n%s", output)
+	}
+	if strings.Contains(output, "private") {
+		t.Errorf("Unexported field should be skipped, got:\n%s", output)
+	}
+	if !strings.Contains(output, `"github.com/donutnomad/gg"`) {
+		t.Errorf("Expected import for named type, got:\n%s", output)
+	}
+}
+
+func TestValueOf_ElidedElements(t *testing.T) {
+	gen := New()
+
+	got := gen.ValueOf([]*valueOfUser{{ID: 1}, {ID: 2}})
+
+	compareAST(t, `[]*gg.valueOfUser{
+{ID: 1},
+{ID: 2},
+}`, got.(*islice).String())
+}
+
+func TestValueOf_NamedBasic(t *testing.T) {
+	gen := New()
+
+	compareAST(t, "gg.valueOfStatus(3)", gen.ValueOf(valueOfStatus(3)).(*Group).String())
+}
+
+func TestValueOf_Stdlib(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"time utc", time.Date(2024, time.March, 4, 5, 6, 7, 8, time.UTC), "time.Date(2024, time.March, 4, 5, 6, 7, 8, time.UTC)"},
+		{"time fixed zone", time.Date(2024, time.March, 4, 0, 0, 0, 0, time.FixedZone("CST", 8*3600)), `time.Date(2024, time.March, 4, 0, 0, 0, 0, time.FixedZone("CST", 28800))`},
+		{"zero time", time.Time{}, "time.Time{}"},
+		{"duration", 90 * time.Second, "90 * time.Second"},
+		{"duration unit", time.Hour, "time.Hour"},
+		{"zero duration", time.Duration(0), "time.Duration(0)"},
+		{"big int", big.NewInt(12), "big.NewInt(12)"},
+		{"huge big int", new(big.Int).Lsh(big.NewInt(1), 100), `func() *big.Int { v, _ := new(big.Int).SetString("1267650600228229401496703205376", 10); return v }()`},
+		{"foreign named type", url.Values{"q": {"go"}}, `url.Values{"q": {"go"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := New()
+			n := gen.ValueOf(tt.value)
+
+			buf := pool.Get()
+			defer buf.Free()
+			n.render(buf)
+
+			compareAST(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestValueOf_Panics(t *testing.T) {
+	t.Run("channel", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected panic for channel value")
+			}
+		}()
+		New().ValueOf(make(chan int))
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected panic for cyclic value")
+			}
+		}()
+		u := &valueOfUser{}
+		u.Parent = u
+		New().ValueOf(u)
+	})
+}
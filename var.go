@@ -10,10 +10,10 @@ func Var() *ivar {
 	i := &ivar{
 		items: newGroup("(", ")", "\n"),
 	}
-	i.items.omitWrapIf = func() bool {
+	i.items.omitWrapIf = func(g *Group) bool {
 		// We only need to omit wrap while length == 1.
 		// NewIf length == 0, we need to keep it, or it will be invalid expr.
-		return i.items.length() == 1
+		return g.length() == 1
 	}
 	return i
 }
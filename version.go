@@ -0,0 +1,195 @@
+package gg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SetGoVersion sets the Go version targeted by the generated file, like
+// `1.21`, see GoVersion. Version dependent nodes, like Any and ForN, use
+// the spelling supported by it, Validate reports generics before go1.18
+// and TypeCheck reports all language features which are too new. It
+// panics if the version is invalid.
+//
+// Example:
+//
+//	gen.SetGoVersion("1.17")
+//	gen.Body().NewVar().AddField("v", gg.Any()) // => var v interface{}
+func (g *Generator) SetGoVersion(version string) *Generator {
+	g.goVersion = normalizeGoVersion(version)
+	return g
+}
+
+// GoVersion returns the Go version set by SetGoVersion, it is empty if the
+// generated code targets no specific version.
+func (g *Generator) GoVersion() string {
+	return g.goVersion
+}
+
+// ReadGoVersion returns the go directive of the go.mod file in dir or in
+// the nearest parent directory, like `1.21`.
+//
+// Example:
+//
+//	version, err := gg.ReadGoVersion("./svc")
+//	if err != nil {
+//		return err
+//	}
+//	gen.SetGoVersion(version)
+func ReadGoVersion(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, "go.mod")
+		data, err := os.ReadFile(path)
+		if err == nil {
+			version := goDirective(data)
+			if version == "" {
+				return "", fmt.Errorf("read go version %s: no go directive", path)
+			}
+			return version, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("read file %s: %s", path, err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("read go version %s: go.mod not found", dir)
+		}
+		dir = parent
+	}
+}
+
+// goDirective returns the version of the go directive of a go.mod file.
+func goDirective(data []byte) string {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) >= 2 && fields[0] == "go" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// normalizeGoVersion removes the `go` prefix of a version, it panics if the
+// version is invalid.
+func normalizeGoVersion(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "go")
+	if _, ok := parseGoVersion(version); version != "" && !ok {
+		panic(fmt.Sprintf("invalid go version %q", version))
+	}
+	return version
+}
+
+// parseGoVersion returns the minor version of a Go 1 version, like 21 for
+// `1.21.3` or `1.22rc1`.
+func parseGoVersion(version string) (int, bool) {
+	rest, ok := strings.CutPrefix(version, "1.")
+	if !ok {
+		return 0, false
+	}
+	end := 0
+	for end < len(rest) && '0' <= rest[end] && rest[end] <= '9' {
+		end++
+	}
+	minor, err := strconv.Atoi(rest[:end])
+	return minor, err == nil
+}
+
+// goVersionAtLeast reports whether the generator of w targets go1.minor or
+// later. Without a target version only the spelling supported by every
+// version is used, so it is false.
+func goVersionAtLeast(w io.Writer, minor int) bool {
+	ctx, ok := w.(*RenderContext)
+	if !ok || ctx.gen == nil {
+		return false
+	}
+	return ctx.gen.goVersionAtLeast(minor)
+}
+
+func (g *Generator) goVersionAtLeast(minor int) bool {
+	v, ok := parseGoVersion(g.goVersion)
+	return ok && v >= minor
+}
+
+// typesGoVersion returns the version for types.Config, it is empty for no
+// specific version.
+func (g *Generator) typesGoVersion() string {
+	if g.goVersion == "" {
+		return ""
+	}
+	return "go" + g.goVersion
+}
+
+// iany has a field, so each Any is a distinct node, pointers to zero-size
+// values could be equal.
+type iany struct {
+	_ byte
+}
+
+// Any returns the empty interface type, which is rendered as `any` if the
+// generator targets go1.18 or later, and as `interface{}` otherwise.
+//
+// Example:
+//
+//	gen.Body().NewFunction("Print").AddParameter("v", gg.Any())
+//	// go1.18+: func Print(v any)
+//	// go1.17:  func Print(v interface{})
+func Any() Node {
+	return &iany{}
+}
+
+func (*iany) render(w io.Writer) {
+	if goVersionAtLeast(w, 18) {
+		writeString(w, "any")
+		return
+	}
+	writeString(w, "interface{}")
+}
+
+// irangeInt is the clause of a loop which runs n times.
+type irangeInt struct {
+	name string
+	n    Node
+}
+
+func (i *irangeInt) render(w io.Writer) {
+	if goVersionAtLeast(w, 22) {
+		if i.name != "" {
+			writeString(w, i.name, " := ")
+		}
+		writeString(w, "range ")
+		i.n.render(w)
+		return
+	}
+	name := i.name
+	if name == "" {
+		name = "i"
+	}
+	writeString(w, name, " := 0; ", name, " < ")
+	i.n.render(w)
+	writeString(w, "; ", name, "++")
+}
+
+// ForN returns a loop which runs n times with the counter name, it is
+// rendered as a range over int if the generator targets go1.22 or later,
+// and as a three-clause loop otherwise. The counter is omitted from the
+// range if name is empty, the three-clause loop names it `i`.
+//
+// Example:
+//
+//	gg.ForN("i", "len(items)").AddBody("sum += items[i]")
+//	// go1.22+: for i := range len(items) {
+//	// go1.21:  for i := 0; i < len(items); i++ {
+func ForN(name string, n interface{}) *ifor {
+	return For(&irangeInt{name: name, n: parseNode(n)})
+}
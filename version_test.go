@@ -0,0 +1,174 @@
+package gg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerator_SetGoVersion(t *testing.T) {
+	build := func(version string) *Generator {
+		gen := New()
+		gen.SetPackage("main")
+		gen.SetGoVersion(version)
+		gen.Body().NewFunction("Sum").
+			AddParameter("items", "[]int").
+			AddParameter("v", Any()).
+			AddResult("", "int").
+			AddBody(
+				"n := 0",
+				ForN("i", "len(items)").AddBody("n += items[i]"),
+				Return("n"),
+			)
+		return gen
+	}
+
+	compareAST(t, `package main
+
+func Sum(items []int, v interface{}) (int) {
+	n := 0
+	for i := 0; i < len(items); i++ {
+		n += items[i]
+	}
+	return n
+}
+`, build("").String())
+	compareAST(t, `package main
+
+func Sum(items []int, v any) (int) {
+	n := 0
+	for i := 0; i < len(items); i++ {
+		n += items[i]
+	}
+	return n
+}
+`, build("1.21").String())
+	compareAST(t, `package main
+
+func Sum(items []int, v any) (int) {
+	n := 0
+	for i := range len(items) {
+		n += items[i]
+	}
+	return n
+}
+`, build("go1.22.3").String())
+
+	gen := build("go1.22.3")
+	if gen.GoVersion() != "1.22.3" {
+		t.Errorf("Expected the version without prefix, got %s", gen.GoVersion())
+	}
+	if c := gen.Clone().SetGoVersion("1.17"); c.GoVersion() != "1.17" || gen.GoVersion() != "1.22.3" {
+		t.Errorf("Expected the clone to have its own version")
+	}
+	if err := gen.TypeCheck(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for an invalid version")
+		}
+	}()
+	New().SetGoVersion("2.0")
+}
+
+func TestForN_WithoutName(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().NewFunction("main").AddBody(ForN("", Lit(3)).AddBody("println()"))
+	if !strings.Contains(gen.String(), "for i := 0; i < 3; i++") {
+		t.Errorf("Expected a three-clause loop, got:\n%s", gen.String())
+	}
+	gen.SetGoVersion("1.22")
+	if !strings.Contains(gen.String(), "for range 3") {
+		t.Errorf("Expected a range over int, got:\n%s", gen.String())
+	}
+}
+
+func TestGenerator_Validate_GoVersion(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.SetGoVersion("1.17")
+	gen.Body().NewFunction("Map").AddTypeParameter("T", "any")
+	gen.Body().NewFunction("Values").AddResult("", gen.P("example.com/set").Generic("Set", "int"))
+
+	err := gen.Validate()
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || len(vErr.Problems) != 2 {
+		t.Fatalf("Expected 2 problems, got %v", err)
+	}
+	if p := vErr.Problems[0].String(); p != "func Map: type parameters require go1.18, the target is go1.17" {
+		t.Errorf("Unexpected problem: %s", p)
+	}
+	if p := vErr.Problems[1].String(); p != "func Values: type arguments require go1.18, the target is go1.17" {
+		t.Errorf("Unexpected problem: %s", p)
+	}
+
+	if err := gen.SetGoVersion("1.18").Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestGenerator_TypeCheck_GoVersion(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.SetGoVersion("1.20")
+	gen.Body().NewFunction("main").AddBody(
+		For("i := range 10").AddBody("println(i)"),
+		"println(min(1, 5))",
+	)
+
+	err := gen.TypeCheck()
+	var tcErr *TypeCheckError
+	if !errors.As(err, &tcErr) || len(tcErr.Diagnostics) != 2 {
+		t.Fatalf("Expected 2 diagnostics, got %v", err)
+	}
+	if !strings.Contains(tcErr.Diagnostics[0].Msg, "go1.22") || !strings.Contains(tcErr.Diagnostics[1].Msg, "go1.21") {
+		t.Errorf("Unexpected diagnostics: %v", tcErr.Diagnostics)
+	}
+
+	if err := gen.SetGoVersion("1.22").TypeCheck(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestModule_SetGoVersion(t *testing.T) {
+	mod := NewModule("example.com/svc")
+	before := mod.Package("models").File("user.go")
+	mod.SetGoVersion("1.17")
+	after := mod.Package("handler").File("user.go")
+	if before.GoVersion() != "1.17" || after.GoVersion() != "1.17" {
+		t.Errorf("Expected all files to target go1.17, got %q and %q", before.GoVersion(), after.GoVersion())
+	}
+	if !strings.Contains(string(mod.GoMod()), "go 1.17") {
+		t.Errorf("Expected the go directive, got:\n%s", mod.GoMod())
+	}
+	if gomod := string(mod.SetGoVersion("go1.21").GoMod()); !strings.Contains(gomod, "\ngo 1.21\n") {
+		t.Errorf("Expected the version without prefix, got:\n%s", gomod)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for an invalid version")
+		}
+	}()
+	mod.SetGoVersion("2.0")
+}
+
+func TestReadGoVersion(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/svc\n\ngo 1.22.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "internal", "handler")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	version, err := ReadGoVersion(dir)
+	if err != nil || version != "1.22.1" {
+		t.Errorf("Expected 1.22.1, got %q, %v", version, err)
+	}
+}
@@ -0,0 +1,245 @@
+package gg
+
+import "fmt"
+
+// Cursor describes a node encountered during Walk. The node could be
+// replaced or deleted through the cursor.
+type Cursor struct {
+	parent Node
+	ptr    *Node
+}
+
+// Node returns the current node.
+func (c *Cursor) Node() Node {
+	return *c.ptr
+}
+
+// Parent returns the parent of the current node, it is nil for the root.
+func (c *Cursor) Parent() Node {
+	return c.parent
+}
+
+// Replace replaces the current node, node could be a Node, a CustomNode or a
+// string. The children of the new node are walked instead of the old ones.
+func (c *Cursor) Replace(node any) {
+	*c.ptr = parseNode(node)
+}
+
+// Delete removes the current node from its parent Group. It panics if the
+// parent is not a Group.
+func (c *Cursor) Delete() {
+	if _, ok := c.parent.(*Group); !ok {
+		panic(fmt.Errorf("gg: cannot delete %T from %T", *c.ptr, c.parent))
+	}
+	*c.ptr = nil
+}
+
+// Walk traverses the node tree in depth-first order. For every node, pre is
+// called before its children and post is called after them, both could be
+// nil.
+//
+// If pre returns false, the children and the post call of the node are
+// skipped. If post returns false, the traversal stops. Walk returns the
+// root, which may have been replaced.
+//
+// Example:
+//
+//	gg.Walk(gen.Body(), func(c *gg.Cursor) bool {
+//		if s, ok := c.Node().(fmt.Stringer); ok && s.String() == "TODO()" {
+//			c.Delete()
+//		}
+//		return true
+//	}, nil)
+func Walk(root Node, pre, post func(c *Cursor) bool) Node {
+	w := &walker{pre: pre, post: post}
+	w.walk(nil, &root)
+	return root
+}
+
+// Inspect traverses the node tree in depth-first order, the children of a
+// node are skipped if fn returns false.
+//
+// Example:
+//
+//	gg.Inspect(gen.Body(), func(n gg.Node) bool {
+//		// collect nodes
+//		return true
+//	})
+func Inspect(root Node, fn func(n Node) bool) {
+	Walk(root, func(c *Cursor) bool {
+		return fn(c.Node())
+	}, nil)
+}
+
+type walker struct {
+	pre, post func(c *Cursor) bool
+	stop      bool
+}
+
+func (w *walker) walk(parent Node, ptr *Node) {
+	if w.stop || *ptr == nil {
+		return
+	}
+	c := &Cursor{parent: parent, ptr: ptr}
+	if w.pre != nil && !w.pre(c) {
+		return
+	}
+	n := *ptr
+	if n == nil {
+		// Deleted by pre.
+		return
+	}
+	eachChild(n, func(child *Node) {
+		w.walk(n, child)
+	})
+	if w.post != nil && !w.stop && !w.post(c) {
+		w.stop = true
+	}
+}
+
+// eachChild calls fn with a pointer to every child of the node, a new child
+// could be stored through the pointer. Every node type of this package must
+// be listed here.
+func eachChild(node Node, fn func(child *Node)) {
+	switch n := node.(type) {
+	case *Generator:
+		groupChild(&n.g, fn)
+	case *Group:
+		for i := 0; i < len(n.items); {
+			fn(&n.items[i])
+			if n.items[i] == nil {
+				n.items = append(n.items[:i], n.items[i+1:]...)
+				continue
+			}
+			i++
+		}
+	case *icustom:
+		n.eachChild(fn)
+	case *sliceType:
+		fn(&n.elem)
+	case *ptrType:
+		fn(&n.elem)
+	case *mapType:
+		fn(&n.key)
+		fn(&n.value)
+	case *chanType:
+		fn(&n.elem)
+	case *genericType:
+		fn(&n.base)
+		for i := range n.args {
+			fn(&n.args[i])
+		}
+	case *ifunction:
+		nodeChild(&n.receiver, fn)
+		groupChild(&n.typeParams, fn)
+		groupChild(&n.parameters, fn)
+		groupChild(&n.results, fn)
+		groupChild(&n.body, fn)
+		typedChild(&n.call, fn)
+	case *istruct:
+		groupChild(&n.typeParams, fn)
+		groupChild(&n.items, fn)
+	case *iinterface:
+		groupChild(&n.typeParams, fn)
+		groupChild(&n.items, fn)
+	case *isignature:
+		groupChild(&n.comments, fn)
+		groupChild(&n.parameters, fn)
+		groupChild(&n.results, fn)
+	case *ivar:
+		groupChild(&n.items, fn)
+	case *iconst:
+		groupChild(&n.items, fn)
+	case *iimport:
+		groupChild(&n.items, fn)
+	case *iif:
+		fn(&n.judge)
+		groupChild(&n.body, fn)
+		nodeChild(&n.elseNode, fn)
+	case *ifor:
+		fn(&n.judge)
+		groupChild(&n.body, fn)
+	case *iswitch:
+		fn(&n.judge)
+		for i := range n.cases {
+			typedChild(&n.cases[i], fn)
+		}
+		typedChild(&n.defaultCase, fn)
+	case *icase:
+		nodeChild(&n.judge, fn)
+		groupChild(&n.body, fn)
+	case *ireturn:
+		groupChild(&n.items, fn)
+	case *idefer:
+		fn(&n.body)
+	case *icall:
+		nodeChild(&n.owner, fn)
+		groupChild(&n.items, fn)
+		groupChild(&n.calls, fn)
+	case *ivalue:
+		fn(&n.typ)
+		groupChild(&n.items, fn)
+	case *islice:
+		fn(&n.elemType)
+		groupChild(&n.items, fn)
+	case *iarray:
+		fn(&n.elemType)
+		groupChild(&n.items, fn)
+	case *itype:
+		groupChild(&n.typeParams, fn)
+		fn(&n.item)
+	case *ifield:
+		nodeChild(&n.name, fn)
+		nodeChild(&n.typ, fn)
+		nodeChild(&n.value, fn)
+	case *multiNameField:
+		fn(&n.typ)
+	case *iregion:
+		groupChild(&n.body, fn)
+	case *irangeInt:
+		fn(&n.n)
+	}
+	// *istring, *lit, *ipackage, *qualifiedIdent and *iany have no children.
+}
+
+// nodeChild calls fn with an optional child.
+func nodeChild(child *Node, fn func(child *Node)) {
+	if *child != nil {
+		fn(child)
+	}
+}
+
+// groupChild calls fn with a Group child. If it's replaced by another kind of
+// node, the node is wrapped into a copy of the Group, so the delimiters are
+// kept.
+func groupChild(child **Group, fn func(child *Node)) {
+	if *child == nil {
+		return
+	}
+	var n Node = *child
+	fn(&n)
+	switch v := n.(type) {
+	case *Group:
+		*child = v
+	default:
+		g := **child
+		g.items = []Node{v}
+		*child = &g
+	}
+}
+
+// typedChild calls fn with a child of a concrete node type, the child could
+// only be replaced by a node of the same type.
+func typedChild[T Node](child *T, fn func(child *Node)) {
+	var zero T
+	if Node(*child) == Node(zero) {
+		return
+	}
+	var n Node = *child
+	fn(&n)
+	v, ok := n.(T)
+	if !ok {
+		panic(fmt.Errorf("gg: cannot replace %T with %T", *child, n))
+	}
+	*child = v
+}
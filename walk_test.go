@@ -0,0 +1,167 @@
+package gg
+
+import (
+	"strings"
+	"testing"
+)
+
+func newWalkGenerator() *Generator {
+	gen := New()
+	gen.SetPackage("main")
+	models := gen.P("github.com/example/models")
+
+	fn := gen.Body().NewFunction("Handle").
+		AddParameter("req", models.Ptr("Request")).
+		AddResult("", "error")
+	sw := Switch("req.Kind")
+	sw.NewCase(models.Dot("KindA")).AddBody(Return(String("nil")))
+	sw.NewDefault().AddBody(Return(models.Call("Unsupported")))
+	fn.AddBody(
+		String("TODO()"),
+		NewInlineGroup().Append("_ = ", Value(models.Type("Response")).AddField("OK", Lit(true))),
+		sw,
+	)
+	return gen
+}
+
+func TestInspect(t *testing.T) {
+	gen := newWalkGenerator()
+
+	var names []string
+	Inspect(gen, func(n Node) bool {
+		if q, ok := n.(*qualifiedIdent); ok {
+			names = append(names, q.name)
+		}
+		return true
+	})
+
+	// The call of Unsupported is rendered as `models.` with the owner.
+	expected := []string{"Request", "Response", "KindA", ""}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestWalk_SkipChildren(t *testing.T) {
+	gen := newWalkGenerator()
+
+	count := 0
+	Walk(gen.Body(), func(c *Cursor) bool {
+		if _, ok := c.Node().(*iswitch); ok {
+			return false
+		}
+		if _, ok := c.Node().(*qualifiedIdent); ok {
+			count++
+		}
+		return true
+	}, nil)
+
+	if count != 2 {
+		t.Errorf("Expected 2 identifiers outside the switch, got %d", count)
+	}
+}
+
+func TestWalk_PostOrder(t *testing.T) {
+	g := NewGroup().Append(Return(String("a")))
+
+	var order []string
+	Walk(g, func(c *Cursor) bool {
+		order = append(order, "pre:"+kindOf(c.Node()))
+		return true
+	}, func(c *Cursor) bool {
+		order = append(order, "post:"+kindOf(c.Node()))
+		return true
+	})
+
+	expected := "pre:group,pre:return,pre:group,pre:string,post:string,post:group,post:return,post:group"
+	if got := strings.Join(order, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func kindOf(n Node) string {
+	switch n.(type) {
+	case *Group:
+		return "group"
+	case *ireturn:
+		return "return"
+	case *istring:
+		return "string"
+	}
+	return "other"
+}
+
+func TestWalk_ReplaceAndDelete(t *testing.T) {
+	gen := newWalkGenerator()
+
+	Walk(gen.Body(), func(c *Cursor) bool {
+		if s, ok := c.Node().(*istring); ok {
+			switch string(*s) {
+			case "TODO()":
+				c.Delete()
+			case "nil":
+				c.Replace(String("ErrNotFound"))
+			}
+		}
+		return true
+	}, nil)
+
+	output := gen.String()
+	if strings.Contains(output, "TODO()") {
+		t.Errorf("Expected TODO() to be deleted, got:\n%s", output)
+	}
+	if !strings.Contains(output, "return ErrNotFound") {
+		t.Errorf("Expected replaced return value, got:\n%s", output)
+	}
+}
+
+func TestWalk_ReplaceGroupChild(t *testing.T) {
+	fn := Function("f")
+	fn.AddBody(String("a()"))
+
+	Walk(fn, func(c *Cursor) bool {
+		if c.Node() == Node(fn.body) {
+			c.Replace(String("b()"))
+			return false
+		}
+		return true
+	}, nil)
+
+	compareAST(t, "func f() {\nb()\n}", renderString(fn))
+}
+
+func TestWalk_CustomNode(t *testing.T) {
+	inner := String("x")
+	g := NewGroup().Append(&testWrapper{inner: inner})
+
+	found := false
+	Inspect(g, func(n Node) bool {
+		if _, ok := AsCustom(n); ok {
+			found = true
+		}
+		if n == Node(inner) && !found {
+			t.Error("Expected the custom node before its child")
+		}
+		return true
+	})
+	if !found {
+		t.Error("Expected the custom node to be visited")
+	}
+}
+
+func TestMerge_ValueType(t *testing.T) {
+	gen1 := New()
+	gen1.SetPackage("main")
+	gen1.P("github.com/other/models")
+
+	gen2 := New()
+	gen2.SetPackage("main")
+	models := gen2.P("github.com/example/models")
+	gen2.Body().NewVar().AddField("v", Value(models.Type("User")))
+
+	gen1.Merge(gen2)
+
+	if output := gen1.String(); !strings.Contains(output, "models2.User{}") {
+		t.Errorf("Expected renamed composite literal type, got:\n%s", output)
+	}
+}
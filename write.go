@@ -0,0 +1,158 @@
+package gg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// defaultFileMode is the mode of new files while WriteOptions.Mode is zero.
+const defaultFileMode fs.FileMode = 0644
+
+// WriteOptions controls how a generated file is written.
+type WriteOptions struct {
+	// Mode is the permission of the file. Zero keeps the mode of an existing
+	// file, and uses 0644 for a new one.
+	Mode fs.FileMode
+	// DryRun compares the output with the file on disk without touching the
+	// file system, the difference is returned in WriteResult.Diff.
+	DryRun bool
+}
+
+// FileStatus tells how a file is changed by a write.
+type FileStatus int
+
+const (
+	FileUnchanged FileStatus = iota
+	FileCreated
+	FileModified
+)
+
+func (s FileStatus) String() string {
+	switch s {
+	case FileCreated:
+		return "created"
+	case FileModified:
+		return "modified"
+	}
+	return "unchanged"
+}
+
+// WriteResult describes a written file.
+type WriteResult struct {
+	Path   string
+	Status FileStatus
+	// Changed reports whether the file has been created or changed. A file
+	// with the same content and mode is not touched, so its mtime is kept.
+	Changed bool
+	// Diff is the unified diff from the file on disk to the output, it is
+	// only computed for a dry run.
+	Diff string
+}
+
+// WriteFileWith renders the file into memory first and writes it through a
+// temporary file which is renamed to path, so path is never left half
+// written. The file is not written if it has the same content already.
+// The user regions (see UserRegion) of an existing file are kept.
+//
+// With DryRun, nothing is written and the result tells whether the file is
+// up to date, which could be used to check generated code in CI:
+//
+//	res, _ := gen.WriteFileWith("zz_generated.go", gg.WriteOptions{DryRun: true})
+//	if res.Changed {
+//		fmt.Print(res.Diff)
+//		os.Exit(1)
+//	}
+//
+// Example:
+//
+//	res, err := gen.WriteFileWith("zz_generated.go", gg.WriteOptions{Mode: 0444})
+//	if err == nil && res.Changed {
+//		log.Printf("updated %s", res.Path)
+//	}
+func (g *Generator) WriteFileWith(path string, opts WriteOptions) (WriteResult, error) {
+	buf := pool.Get()
+	defer buf.Free()
+	if err := g.renderChecked(buf); err != nil {
+		return WriteResult{Path: path}, fmt.Errorf("write file %s: %s", path, err)
+	}
+	return writeFile(path, buf.Bytes(), opts)
+}
+
+// writeFile writes data into path atomically, unless the file has the same
+// content and mode.
+func writeFile(path string, data []byte, opts WriteOptions) (WriteResult, error) {
+	res := WriteResult{Path: path}
+
+	mode := opts.Mode
+	var existing []byte
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		res.Status = FileModified
+		if mode == 0 {
+			mode = info.Mode().Perm()
+		}
+		if info.Mode().IsRegular() {
+			if existing, err = os.ReadFile(path); err != nil {
+				return res, fmt.Errorf("read file %s: %s", path, err)
+			}
+			if data, err = restoreUserRegions(existing, data); err != nil {
+				return res, fmt.Errorf("write file %s: %s", path, err)
+			}
+			if info.Mode().Perm() == mode && bytes.Equal(existing, data) {
+				res.Status = FileUnchanged
+				return res, nil
+			}
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		if _, err := restoreUserRegions(nil, data); err != nil {
+			return res, fmt.Errorf("write file %s: %s", path, err)
+		}
+		res.Status = FileCreated
+		if mode == 0 {
+			mode = defaultFileMode
+		}
+	default:
+		return res, fmt.Errorf("create file %s: %s", path, err)
+	}
+	res.Changed = true
+
+	if opts.DryRun {
+		from := filepath.ToSlash(path)
+		if res.Status == FileCreated {
+			from = ""
+		}
+		res.Diff = unifiedDiff(from, filepath.ToSlash(path), existing, data)
+		return res, nil
+	}
+
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return res, fmt.Errorf("create file %s: %s", path, err)
+	}
+	// Remove the temporary file if it's not renamed.
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return res, fmt.Errorf("write file %s: %s", path, err)
+	}
+	return res, nil
+}
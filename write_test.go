@@ -0,0 +1,216 @@
+package gg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGenerator_WriteFileWith(t *testing.T) {
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().NewFunction("main")
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	res, err := gen.WriteFileWith(path, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Changed || res.Path != path {
+		t.Errorf("Expected a new file to be changed, got %+v", res)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %v", info.Mode().Perm())
+	}
+
+	// An identical file is not touched.
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	res, err = gen.WriteFileWith(path, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Changed {
+		t.Errorf("Expected an identical file not to be changed")
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(old) {
+		t.Errorf("Expected mtime to be kept, got %v", info.ModTime())
+	}
+
+	gen.Body().NewFunction("helper")
+	res, err = gen.WriteFileWith(path, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !res.Changed || string(data) != gen.String() {
+		t.Errorf("Expected the file to be updated, got:\n%s", data)
+	}
+
+	if runtime.GOOS != "windows" {
+		res, err = gen.WriteFileWith(path, WriteOptions{Mode: 0600})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info, _ := os.Stat(path); !res.Changed || info.Mode().Perm() != 0600 {
+			t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+		}
+		// Zero keeps the mode of the existing file.
+		gen.Body().NewFunction("other")
+		if err := gen.WriteFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("Expected mode to be kept, got %v", info.Mode().Perm())
+		}
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files left, got %d entries", len(entries))
+	}
+}
+
+func TestGenerator_WriteFile_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			gen := New()
+			gen.SetPackage("main")
+			gen.Body().NewFunction(fmt.Sprintf("F%d", i)).AddBody(strings.Repeat("println()\n", i))
+			if err := gen.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.go", i))); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 50; i++ {
+		data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("f%d.go", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), fmt.Sprintf("func F%d()", i)) || strings.Count(string(data), "println()") != i {
+			t.Errorf("File %d is corrupted:\n%s", i, data)
+		}
+	}
+}
+
+func TestGenerator_WriteFilePanic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := New()
+	gen.SetPackage("main")
+	gen.Body().Append(&panicNode{})
+	func() {
+		defer func() { _ = recover() }()
+		_ = gen.WriteFile(path)
+	}()
+
+	if data, _ := os.ReadFile(path); string(data) != "package main\n" {
+		t.Errorf("Expected the file to be kept when rendering fails, got:\n%s", data)
+	}
+}
+
+type panicNode struct{}
+
+func (*panicNode) RenderNode(ctx *RenderContext) {
+	ctx.WriteString("func broken() {")
+	panic("render failed")
+}
+
+func TestModule_WriteDirWith(t *testing.T) {
+	mod := NewModule("github.com/example/svc")
+	mod.Package("models").File("user.go").Body().NewStruct("User")
+
+	dir := t.TempDir()
+	results, err := mod.WriteDirWith(dir, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !results[0].Changed || !results[1].Changed {
+		t.Fatalf("Expected go.mod and user.go to be written, got %+v", results)
+	}
+
+	results, err = mod.WriteDirWith(dir, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results {
+		if res.Changed {
+			t.Errorf("Expected %s not to be changed", res.Path)
+		}
+	}
+}
+
+func TestGoPackage_DryRun(t *testing.T) {
+	pkg := NewPackage("models", "")
+	pkg.File("user.go").Body().NewStruct("User")
+	pkg.File("order.go").Body().NewStruct("Order")
+	pkg.File("item.go").Body().NewStruct("Item")
+
+	dir := t.TempDir()
+	if err := pkg.WriteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	pkg.File("order.go").Body().NewStruct("OrderItem")
+	pkg.File("new.go").Body().NewStruct("New")
+	if err := os.Remove(filepath.Join(dir, "item.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	before, _ := os.ReadFile(filepath.Join(dir, "order.go"))
+	results, err := pkg.WriteDirWith(dir, WriteOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var summary []string
+	for _, res := range results {
+		summary = append(summary, filepath.Base(res.Path)+" "+res.Status.String())
+	}
+	if got := strings.Join(summary, ", "); got != "user.go unchanged, order.go modified, item.go created, new.go created" {
+		t.Errorf("Unexpected summary: %s", got)
+	}
+
+	order := results[1]
+	if !order.Changed || !strings.Contains(order.Diff, "+type OrderItem struct") || !strings.HasPrefix(order.Diff, "--- ") {
+		t.Errorf("Unexpected diff:\n%s", order.Diff)
+	}
+	if results[0].Diff != "" || !strings.HasPrefix(results[2].Diff, "--- /dev/null\n") {
+		t.Errorf("Unexpected diffs: %q, %q", results[0].Diff, results[2].Diff)
+	}
+
+	// Nothing is written.
+	if after, _ := os.ReadFile(filepath.Join(dir, "order.go")); string(after) != string(before) {
+		t.Errorf("Expected order.go not to be written")
+	}
+	for _, name := range []string{"item.go", "new.go"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be created", name)
+		}
+	}
+	if _, err := NewModule("example.com/m").WriteDirWith(filepath.Join(dir, "mod"), WriteOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mod")); !os.IsNotExist(err) {
+		t.Errorf("Expected no directory to be created")
+	}
+}